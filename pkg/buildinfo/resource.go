@@ -0,0 +1,38 @@
+package buildinfo
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+// Attributes returns the OpenTelemetry resource attributes of the build: the
+// service name and version, the commit as the VCS revision, and the build date
+// and source. Empty values are left out.
+func (u BuildInfo) Attributes() []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+
+	for _, kv := range []attribute.KeyValue{
+		semconv.ServiceName(u.Name()),
+		semconv.ServiceVersion(u.Version()),
+		semconv.VCSRefHeadRevision(u.Commit()),
+		attribute.String("build.date", u.Date()),
+		attribute.String("build.source", u.BuildSource()),
+	} {
+		if kv.Value.AsString() != "" {
+			attrs = append(attrs, kv)
+		}
+	}
+
+	return attrs
+}
+
+// Resource returns the default OpenTelemetry resource, which honours the
+// OTEL_RESOURCE_ATTRIBUTES and OTEL_SERVICE_NAME environment variables, merged
+// with the attributes of the build and then the given attributes.
+func (u BuildInfo) Resource(attrs ...attribute.KeyValue) (*resource.Resource, error) {
+	return resource.Merge(
+		resource.Default(),
+		resource.NewWithAttributes(semconv.SchemaURL, append(u.Attributes(), attrs...)...),
+	)
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 
+	"go.opentelemetry.io/otel/log/global"
 	"golang.org/x/text/message"
 
 	"github.com/merlindorin/go-shared/pkg/logger"
@@ -12,17 +13,22 @@ import (
 )
 
 // Commons defines the common flags and embedded commands for printing version
-// and licence information, utilized by the command-line interface.
+// and licence information, utilized by the command-line interface. The
+// telemetry flags set up the OpenTelemetry providers with Telemetry.Setup.
 type Commons struct {
 	Development bool   `short:"D" env:"DEBUG,DEV,DEVELOPMENT" help:"Set to true to enable development mode with debug-level logging."`
 	Level       string `short:"l" env:"LOG_LEVEL" help:"Specify the logging level, options are: debug, info, warn, error, fatal." default:"info"`
 	Lang        string `env:"LANG" help:"Specify the print lang for tailored message." default:"en"`
 
+	Telemetry Telemetry `embed:""`
+
 	Version Version `cmd:"" help:"Display version information."`
 	Licence Licence `cmd:"" help:"Show the application's licence."`
 }
 
 // Logger initializes a new zap.Logger based on the Development and Level fields in the commons struct.
+// When a telemetry exporter is configured, the records are also emitted through the global
+// OpenTelemetry logger provider set by Telemetry.Setup.
 // It returns the configured logger or an error if the logging level is invalid or the logger cannot be created.
 func (c *Commons) Logger() (*zap.Logger, error) {
 	level, err := zapcore.ParseLevel(c.Level)
@@ -34,7 +40,12 @@ func (c *Commons) Logger() (*zap.Logger, error) {
 		level = zapcore.DebugLevel
 	}
 
-	return logger.New(level, c.Development)
+	var opts []zap.Option
+	if c.Telemetry.Enabled() {
+		opts = append(opts, logger.WithOtelLogs(global.GetLoggerProvider()))
+	}
+
+	return logger.New(level, c.Development, opts...)
 }
 
 // MustLogger will panic if a logger can't be provided.
@@ -0,0 +1,264 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutlog"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/log/global"
+	"go.opentelemetry.io/otel/propagation"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/merlindorin/go-shared/pkg/buildinfo"
+)
+
+// Exporters supported by Telemetry. ExporterConsole and ExporterOTLP are the
+// values of the OpenTelemetry specification, the latter using the OTLP protocol.
+const (
+	ExporterNone     = "none"
+	ExporterStdout   = "stdout"
+	ExporterOTLPGRPC = "otlp-grpc"
+	ExporterOTLPHTTP = "otlp-http"
+	ExporterConsole  = "console"
+	ExporterOTLP     = "otlp"
+)
+
+// OTLP protocols of ExporterOTLP.
+const (
+	ProtocolGRPC = "grpc"
+	ProtocolHTTP = "http/protobuf"
+)
+
+// Telemetry defines the flags configuring the OpenTelemetry tracer, meter and
+// logger providers of an application. It is embedded in Commons and can be embedded
+// on its own in a command.
+//
+// The exporter of each signal is read from the OTEL_TRACES_EXPORTER,
+// OTEL_METRICS_EXPORTER and OTEL_LOGS_EXPORTER environment variables, and
+// defaults to the exporter of the otel-exporter flag. The OTEL_RESOURCE_ATTRIBUTES
+// environment variable is honoured by the resource itself.
+type Telemetry struct {
+	Exporter           string            `name:"otel-exporter" enum:"none,stdout,otlp-grpc,otlp-http" help:"Set the telemetry exporter, options are: none, stdout, otlp-grpc, otlp-http." default:"none"`
+	TracesExporter     string            `name:"otel-traces-exporter" env:"OTEL_TRACES_EXPORTER" help:"Override the exporter of the traces, options are: none, console, otlp, stdout, otlp-grpc, otlp-http."`
+	MetricsExporter    string            `name:"otel-metrics-exporter" env:"OTEL_METRICS_EXPORTER" help:"Override the exporter of the metrics, options are: none, console, otlp, stdout, otlp-grpc, otlp-http."`
+	LogsExporter       string            `name:"otel-logs-exporter" env:"OTEL_LOGS_EXPORTER" help:"Override the exporter of the logs, options are: none, console, otlp, stdout, otlp-grpc, otlp-http."`
+	Protocol           string            `name:"otel-protocol" env:"OTEL_EXPORTER_OTLP_PROTOCOL" enum:"grpc,http/protobuf" help:"Set the protocol of the otlp exporter, options are: grpc, http/protobuf." default:"http/protobuf"`
+	Endpoint           string            `name:"otel-endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT" help:"Specify the URL of the OTLP endpoint, an http scheme disables TLS."`
+	SampleRatio        float64           `name:"otel-sample-ratio" env:"OTEL_TRACES_SAMPLER_ARG" help:"Set the ratio of the traces sampled, between 0 and 1." default:"1"`
+	MetricInterval     time.Duration     `name:"otel-metric-interval" env:"OTEL_METRIC_EXPORT_INTERVAL" help:"Set the interval between two metric exports." default:"1m"`
+	ResourceAttributes map[string]string `name:"otel-resource-attributes" mapsep:"," help:"Add resource attributes, as key=value pairs separated by commas."`
+}
+
+// Providers holds the tracer, meter and logger providers built by Telemetry.
+type Providers struct {
+	TracerProvider *sdktrace.TracerProvider
+	MeterProvider  *sdkmetric.MeterProvider
+	LoggerProvider *sdklog.LoggerProvider
+}
+
+// Shutdown flushes and stops the providers.
+func (p *Providers) Shutdown(ctx context.Context) error {
+	return errors.Join(
+		p.TracerProvider.Shutdown(ctx),
+		p.MeterProvider.Shutdown(ctx),
+		p.LoggerProvider.Shutdown(ctx),
+	)
+}
+
+// Enabled reports whether an exporter is configured for any signal.
+func (t *Telemetry) Enabled() bool {
+	return t.exporter(t.TracesExporter) != ExporterNone ||
+		t.exporter(t.MetricsExporter) != ExporterNone ||
+		t.exporter(t.LogsExporter) != ExporterNone
+}
+
+// exporter returns the exporter of a signal, given its override, with the
+// values of the specification resolved to the exporters they stand for.
+func (t *Telemetry) exporter(override string) string {
+	exporter := override
+	if exporter == "" {
+		exporter = t.Exporter
+	}
+
+	switch exporter {
+	case "":
+		return ExporterNone
+	case ExporterConsole:
+		return ExporterStdout
+	case ExporterOTLP:
+		if t.Protocol == ProtocolGRPC {
+			return ExporterOTLPGRPC
+		}
+		return ExporterOTLPHTTP
+	default:
+		return exporter
+	}
+}
+
+// Providers builds the tracer, meter and logger providers exporting to the configured
+// exporters, with a resource pre-populated from the build information. Nothing
+// is exported for a signal with the none exporter. Spans are sampled with the sample ratio,
+// unless their parent is sampled.
+func (t *Telemetry) Providers(ctx context.Context, info buildinfo.BuildInfo) (*Providers, error) {
+	attrs := make([]attribute.KeyValue, 0, len(t.ResourceAttributes))
+	for _, key := range slices.Sorted(maps.Keys(t.ResourceAttributes)) {
+		attrs = append(attrs, attribute.String(strings.TrimSpace(key), strings.TrimSpace(t.ResourceAttributes[key])))
+	}
+
+	res, err := info.Resource(attrs...)
+	if err != nil {
+		return nil, fmt.Errorf("cannot build telemetry resource: %w", err)
+	}
+
+	traceOptions := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(t.SampleRatio))),
+	}
+	metricOptions := []sdkmetric.Option{sdkmetric.WithResource(res)}
+	logOptions := []sdklog.LoggerProviderOption{sdklog.WithResource(res)}
+
+	if exporter := t.exporter(t.TracesExporter); exporter != ExporterNone {
+		spanExporter, err := t.spanExporter(ctx, exporter)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create %s span exporter: %w", exporter, err)
+		}
+
+		traceOptions = append(traceOptions, sdktrace.WithBatcher(spanExporter))
+	}
+
+	if exporter := t.exporter(t.MetricsExporter); exporter != ExporterNone {
+		metricExporter, err := t.metricExporter(ctx, exporter)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create %s metric exporter: %w", exporter, err)
+		}
+
+		metricOptions = append(metricOptions, sdkmetric.WithReader(
+			sdkmetric.NewPeriodicReader(metricExporter, sdkmetric.WithInterval(t.MetricInterval)),
+		))
+	}
+
+	if exporter := t.exporter(t.LogsExporter); exporter != ExporterNone {
+		logExporter, err := t.logExporter(ctx, exporter)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create %s log exporter: %w", exporter, err)
+		}
+
+		logOptions = append(logOptions, sdklog.WithProcessor(sdklog.NewBatchProcessor(logExporter)))
+	}
+
+	return &Providers{
+		TracerProvider: sdktrace.NewTracerProvider(traceOptions...),
+		MeterProvider:  sdkmetric.NewMeterProvider(metricOptions...),
+		LoggerProvider: sdklog.NewLoggerProvider(logOptions...),
+	}, nil
+}
+
+// Setup builds the providers with Providers and sets them as the global tracer,
+// meter and logger providers, along with the W3C trace context and baggage propagators.
+// The returned function flushes and stops the providers; it must be called
+// before the application exits.
+func (t *Telemetry) Setup(ctx context.Context, info buildinfo.BuildInfo) (func(context.Context) error, error) {
+	providers, err := t.Providers(ctx, info)
+	if err != nil {
+		return nil, err
+	}
+
+	otel.SetTracerProvider(providers.TracerProvider)
+	otel.SetMeterProvider(providers.MeterProvider)
+	global.SetLoggerProvider(providers.LoggerProvider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	return providers.Shutdown, nil
+}
+
+// spanExporter creates the given span exporter.
+func (t *Telemetry) spanExporter(ctx context.Context, exporter string) (sdktrace.SpanExporter, error) {
+	switch exporter {
+	case ExporterStdout:
+		return stdouttrace.New()
+	case ExporterOTLPGRPC:
+		var opts []otlptracegrpc.Option
+		if t.Endpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpointURL(t.Endpoint))
+		}
+
+		return otlptracegrpc.New(ctx, opts...)
+	case ExporterOTLPHTTP:
+		var opts []otlptracehttp.Option
+		if t.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(strings.TrimSuffix(t.Endpoint, "/")+"/v1/traces"))
+		}
+
+		return otlptracehttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unknown exporter %q", exporter)
+	}
+}
+
+// metricExporter creates the given metric exporter.
+func (t *Telemetry) metricExporter(ctx context.Context, exporter string) (sdkmetric.Exporter, error) {
+	switch exporter {
+	case ExporterStdout:
+		return stdoutmetric.New()
+	case ExporterOTLPGRPC:
+		var opts []otlpmetricgrpc.Option
+		if t.Endpoint != "" {
+			opts = append(opts, otlpmetricgrpc.WithEndpointURL(t.Endpoint))
+		}
+
+		return otlpmetricgrpc.New(ctx, opts...)
+	case ExporterOTLPHTTP:
+		var opts []otlpmetrichttp.Option
+		if t.Endpoint != "" {
+			opts = append(opts, otlpmetrichttp.WithEndpointURL(strings.TrimSuffix(t.Endpoint, "/")+"/v1/metrics"))
+		}
+
+		return otlpmetrichttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unknown exporter %q", exporter)
+	}
+}
+
+// logExporter creates the given log exporter.
+func (t *Telemetry) logExporter(ctx context.Context, exporter string) (sdklog.Exporter, error) {
+	switch exporter {
+	case ExporterStdout:
+		return stdoutlog.New()
+	case ExporterOTLPGRPC:
+		var opts []otlploggrpc.Option
+		if t.Endpoint != "" {
+			opts = append(opts, otlploggrpc.WithEndpointURL(t.Endpoint))
+		}
+
+		return otlploggrpc.New(ctx, opts...)
+	case ExporterOTLPHTTP:
+		var opts []otlploghttp.Option
+		if t.Endpoint != "" {
+			opts = append(opts, otlploghttp.WithEndpointURL(strings.TrimSuffix(t.Endpoint, "/")+"/v1/logs"))
+		}
+
+		return otlploghttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unknown exporter %q", exporter)
+	}
+}
@@ -0,0 +1,330 @@
+package cmd_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/kong"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	collogpb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/merlindorin/go-shared/pkg/buildinfo"
+	"github.com/merlindorin/go-shared/pkg/cmd"
+	"github.com/merlindorin/go-shared/pkg/logger"
+)
+
+// receiver is an in-process OTLP receiver recording the resources exported.
+type receiver struct {
+	coltracepb.UnimplementedTraceServiceServer
+	colmetricpb.UnimplementedMetricsServiceServer
+	collogpb.UnimplementedLogsServiceServer
+
+	mu      sync.Mutex
+	spans   []*resourcepb.Resource
+	metrics []*resourcepb.Resource
+	logs    []*resourcepb.Resource
+}
+
+func (r *receiver) Export(_ context.Context, req *coltracepb.ExportTraceServiceRequest) (
+	*coltracepb.ExportTraceServiceResponse, error,
+) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, rs := range req.GetResourceSpans() {
+		r.spans = append(r.spans, rs.GetResource())
+	}
+
+	return &coltracepb.ExportTraceServiceResponse{}, nil
+}
+
+// metricsService adapts the receiver to the metrics service, whose Export
+// method conflicts with the trace service.
+type metricsService struct {
+	*receiver
+}
+
+func (m metricsService) Export(_ context.Context, req *colmetricpb.ExportMetricsServiceRequest) (
+	*colmetricpb.ExportMetricsServiceResponse, error,
+) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, rm := range req.GetResourceMetrics() {
+		m.metrics = append(m.metrics, rm.GetResource())
+	}
+
+	return &colmetricpb.ExportMetricsServiceResponse{}, nil
+}
+
+// logsService adapts the receiver to the logs service.
+type logsService struct {
+	*receiver
+}
+
+func (l logsService) Export(_ context.Context, req *collogpb.ExportLogsServiceRequest) (
+	*collogpb.ExportLogsServiceResponse, error,
+) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, rl := range req.GetResourceLogs() {
+		l.logs = append(l.logs, rl.GetResource())
+	}
+
+	return &collogpb.ExportLogsServiceResponse{}, nil
+}
+
+// ServeHTTP receives OTLP over HTTP with protobuf encoding.
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch req.URL.Path {
+	case "/v1/traces":
+		msg := &coltracepb.ExportTraceServiceRequest{}
+		if err = proto.Unmarshal(body, msg); err == nil {
+			_, err = r.Export(req.Context(), msg)
+		}
+	case "/v1/metrics":
+		msg := &colmetricpb.ExportMetricsServiceRequest{}
+		if err = proto.Unmarshal(body, msg); err == nil {
+			_, err = metricsService{r}.Export(req.Context(), msg)
+		}
+	case "/v1/logs":
+		msg := &collogpb.ExportLogsServiceRequest{}
+		if err = proto.Unmarshal(body, msg); err == nil {
+			_, err = logsService{r}.Export(req.Context(), msg)
+		}
+	default:
+		http.NotFound(w, req)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-protobuf")
+}
+
+// attributes returns the string attributes of a resource.
+func attributes(res *resourcepb.Resource) map[string]string {
+	attrs := map[string]string{}
+	for _, kv := range res.GetAttributes() {
+		attrs[kv.GetKey()] = kv.GetValue().GetStringValue()
+	}
+
+	return attrs
+}
+
+func TestTelemetry(t *testing.T) {
+	info := buildinfo.NewBuildInfo("app", "1.2.3", "abcdef", "ci", "2026-01-01")
+
+	export := func(t *testing.T, telemetry *cmd.Telemetry) {
+		t.Helper()
+
+		providers, err := telemetry.Providers(t.Context(), info)
+		require.NoError(t, err)
+
+		_, span := providers.TracerProvider.Tracer("test").Start(t.Context(), "span")
+		span.End()
+
+		counter, err := providers.MeterProvider.Meter("test").Int64Counter("counter")
+		require.NoError(t, err)
+		counter.Add(t.Context(), 1)
+
+		l, err := logger.New(zapcore.InfoLevel, false, logger.WithOtelLogs(providers.LoggerProvider))
+		require.NoError(t, err)
+		l.Info("message")
+
+		require.NoError(t, providers.Shutdown(t.Context()))
+	}
+
+	assertResources := func(t *testing.T, r *receiver) {
+		t.Helper()
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		require.Len(t, r.spans, 1)
+		require.Len(t, r.metrics, 1)
+		require.Len(t, r.logs, 1)
+
+		for _, res := range []*resourcepb.Resource{r.spans[0], r.metrics[0], r.logs[0]} {
+			attrs := attributes(res)
+			assert.Equal(t, "app", attrs["service.name"])
+			assert.Equal(t, "1.2.3", attrs["service.version"])
+			assert.Equal(t, "abcdef", attrs["vcs.ref.head.revision"])
+			assert.Equal(t, "production", attrs["deployment.environment.name"])
+		}
+	}
+
+	t.Run("should parse the flags", func(t *testing.T) {
+		var cli struct {
+			cmd.Telemetry `embed:""`
+		}
+
+		parser, err := kong.New(&cli)
+		require.NoError(t, err)
+
+		_, err = parser.Parse([]string{
+			"--otel-exporter=otlp-http",
+			"--otel-endpoint=http://localhost:4318",
+			"--otel-sample-ratio=0.5",
+			"--otel-resource-attributes=team=core,region=eu",
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, cmd.ExporterOTLPHTTP, cli.Exporter)
+		assert.Equal(t, "http://localhost:4318", cli.Endpoint)
+		assert.InDelta(t, 0.5, cli.SampleRatio, 0)
+		assert.Equal(t, map[string]string{"team": "core", "region": "eu"}, cli.ResourceAttributes)
+	})
+
+	t.Run("should reject unknown exporters", func(t *testing.T) {
+		var cli struct {
+			cmd.Telemetry `embed:""`
+		}
+
+		parser, err := kong.New(&cli)
+		require.NoError(t, err)
+
+		_, err = parser.Parse([]string{"--otel-exporter=zipkin"})
+		assert.Error(t, err)
+	})
+
+	t.Run("should read the exporters of the signals from the environment", func(t *testing.T) {
+		t.Setenv("OTEL_TRACES_EXPORTER", "otlp")
+		t.Setenv("OTEL_METRICS_EXPORTER", "console")
+		t.Setenv("OTEL_LOGS_EXPORTER", "none")
+		t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "grpc")
+		t.Setenv("OTEL_RESOURCE_ATTRIBUTES", "team=a%20b")
+
+		var cli struct {
+			cmd.Telemetry `embed:""`
+		}
+
+		parser, err := kong.New(&cli)
+		require.NoError(t, err)
+
+		_, err = parser.Parse(nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, cmd.ExporterNone, cli.Exporter)
+		assert.Equal(t, cmd.ExporterOTLP, cli.TracesExporter)
+		assert.Equal(t, cmd.ExporterConsole, cli.MetricsExporter)
+		assert.Equal(t, cmd.ExporterNone, cli.LogsExporter)
+		assert.Equal(t, cmd.ProtocolGRPC, cli.Protocol)
+		assert.Empty(t, cli.ResourceAttributes)
+		assert.True(t, cli.Enabled())
+	})
+
+	t.Run("should export only the signals with an exporter", func(t *testing.T) {
+		r := &receiver{}
+		server := httptest.NewServer(r)
+		defer server.Close()
+
+		export(t, &cmd.Telemetry{
+			Exporter:       cmd.ExporterNone,
+			TracesExporter: cmd.ExporterOTLP,
+			Protocol:       cmd.ProtocolHTTP,
+			Endpoint:       server.URL,
+			SampleRatio:    1,
+			MetricInterval: time.Minute,
+		})
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		assert.Len(t, r.spans, 1)
+		assert.Empty(t, r.metrics)
+		assert.Empty(t, r.logs)
+	})
+
+	t.Run("should export nothing with the none exporter", func(t *testing.T) {
+		telemetry := &cmd.Telemetry{Exporter: cmd.ExporterNone, SampleRatio: 1}
+
+		providers, err := telemetry.Providers(t.Context(), info)
+		require.NoError(t, err)
+
+		_, span := providers.TracerProvider.Tracer("test").Start(t.Context(), "span")
+		span.End()
+
+		assert.True(t, span.SpanContext().IsSampled())
+		assert.NoError(t, providers.Shutdown(t.Context()))
+	})
+
+	t.Run("should export over OTLP HTTP", func(t *testing.T) {
+		r := &receiver{}
+		server := httptest.NewServer(r)
+		defer server.Close()
+
+		export(t, &cmd.Telemetry{
+			Exporter:           cmd.ExporterOTLPHTTP,
+			Endpoint:           server.URL,
+			SampleRatio:        1,
+			MetricInterval:     time.Minute,
+			ResourceAttributes: map[string]string{"deployment.environment.name": "production"},
+		})
+
+		assertResources(t, r)
+	})
+
+	t.Run("should export over OTLP gRPC", func(t *testing.T) {
+		r := &receiver{}
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+
+		server := grpc.NewServer()
+		coltracepb.RegisterTraceServiceServer(server, r)
+		colmetricpb.RegisterMetricsServiceServer(server, metricsService{r})
+		collogpb.RegisterLogsServiceServer(server, logsService{r})
+
+		go func() {
+			_ = server.Serve(listener)
+		}()
+		defer server.Stop()
+
+		export(t, &cmd.Telemetry{
+			Exporter:           cmd.ExporterOTLPGRPC,
+			Endpoint:           "http://" + listener.Addr().String(),
+			SampleRatio:        1,
+			MetricInterval:     time.Minute,
+			ResourceAttributes: map[string]string{"deployment.environment.name": "production"},
+		})
+
+		assertResources(t, r)
+	})
+
+	t.Run("should not sample with a zero ratio", func(t *testing.T) {
+		telemetry := &cmd.Telemetry{Exporter: cmd.ExporterNone}
+
+		providers, err := telemetry.Providers(t.Context(), info)
+		require.NoError(t, err)
+
+		_, span := providers.TracerProvider.Tracer("test").Start(t.Context(), "span")
+		span.End()
+
+		assert.False(t, span.SpanContext().IsSampled())
+		assert.NoError(t, providers.Shutdown(t.Context()))
+	})
+}
@@ -8,6 +8,8 @@ import (
 // New initializes and returns a new Zap logger with the specified level and development mode.
 // The development flag determines whether to use a production or development configuration.
 // Additional zap.Options can also be provided to customize logger behavior.
+// Fields created with Context are replaced by the fields of the OpenTelemetry span,
+// and WithOtelLogs tees the records into the OpenTelemetry Logs API.
 func New(level zapcore.Level, development bool, opts ...zap.Option) (*zap.Logger, error) {
 	config := zap.NewProductionConfig()
 	if development {
@@ -16,5 +18,5 @@ func New(level zapcore.Level, development bool, opts ...zap.Option) (*zap.Logger
 
 	config.Level = zap.NewAtomicLevelAt(level)
 
-	return config.Build(opts...)
+	return config.Build(append([]zap.Option{WithTraceCore()}, opts...)...)
 }
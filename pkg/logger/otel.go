@@ -0,0 +1,51 @@
+package logger
+
+import (
+	"go.opentelemetry.io/contrib/bridges/otelzap"
+	"go.opentelemetry.io/otel/log"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// ScopeName is the instrumentation scope of the records emitted by WithOtelLogs.
+const ScopeName = "github.com/merlindorin/go-shared/pkg/logger"
+
+// WithOtelLogs tees the records of the logger into the OpenTelemetry Logs API
+// through provider, whose resource usually comes from buildinfo.BuildInfo.
+// Severities map from the zap levels and structured fields become attributes;
+// a context field, such as the one of Context, sets the context of the record
+// so that it is correlated with its span. Records below the level of the logger
+// are not emitted.
+func WithOtelLogs(provider log.LoggerProvider) zap.Option {
+	return zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		otelCore := otelzap.NewCore(ScopeName, otelzap.WithLoggerProvider(provider))
+
+		return zapcore.NewTee(core, &levelCore{Core: otelCore, enabler: core})
+	})
+}
+
+// levelCore is a zapcore.Core dropping the entries not enabled by enabler.
+type levelCore struct {
+	zapcore.Core
+
+	enabler zapcore.LevelEnabler
+}
+
+// Enabled reports whether both the enabler and the core enable the level.
+func (c *levelCore) Enabled(level zapcore.Level) bool {
+	return c.enabler.Enabled(level) && c.Core.Enabled(level)
+}
+
+// With adds structured context to the core.
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), enabler: c.enabler}
+}
+
+// Check lets the core check the entry if the enabler enables its level.
+func (c *levelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.enabler.Enabled(ent.Level) {
+		return ce
+	}
+
+	return c.Core.Check(ent, ce)
+}
@@ -0,0 +1,80 @@
+package logger_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/merlindorin/go-shared/pkg/buildinfo"
+	"github.com/merlindorin/go-shared/pkg/logger"
+)
+
+// exporter is an in-memory sdklog.Exporter.
+type exporter struct {
+	mu      sync.Mutex
+	records []sdklog.Record
+}
+
+func (e *exporter) Export(_ context.Context, records []sdklog.Record) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, r := range records {
+		e.records = append(e.records, r.Clone())
+	}
+
+	return nil
+}
+
+func (e *exporter) Shutdown(context.Context) error {
+	return nil
+}
+
+func (e *exporter) ForceFlush(context.Context) error {
+	return nil
+}
+
+func TestWithOtelLogs(t *testing.T) {
+	info := buildinfo.NewBuildInfo("app", "1.2.3", "abcdef", "ci", "2026-01-01")
+	res, err := info.Resource()
+	require.NoError(t, err)
+
+	exp := &exporter{}
+	provider := sdklog.NewLoggerProvider(sdklog.WithResource(res), sdklog.WithProcessor(sdklog.NewSimpleProcessor(exp)))
+
+	l, err := logger.New(zapcore.InfoLevel, false, logger.WithOtelLogs(provider))
+	require.NoError(t, err)
+
+	l.Debug("dropped")
+	l.With(zap.String("component", "test")).Warn("message", zap.Int("count", 2), logger.Context(spanContext()))
+
+	require.Len(t, exp.records, 1)
+
+	record := exp.records[0]
+	assert.Equal(t, "message", record.Body().AsString())
+	assert.Equal(t, log.SeverityWarn, record.Severity())
+	assert.Equal(t, "warn", record.SeverityText())
+	assert.Equal(t, trace.SpanContextFromContext(spanContext()).TraceID(), record.TraceID())
+	assert.Equal(t, logger.ScopeName, record.InstrumentationScope().Name)
+
+	attrs := map[string]log.Value{}
+	record.WalkAttributes(func(kv log.KeyValue) bool {
+		attrs[kv.Key] = kv.Value
+		return true
+	})
+	assert.Equal(t, "test", attrs["component"].AsString())
+	assert.Equal(t, int64(2), attrs["count"].AsInt64())
+
+	name, ok := record.Resource().Set().Value("service.name")
+	assert.True(t, ok)
+	assert.Equal(t, attribute.StringValue("app"), name)
+}
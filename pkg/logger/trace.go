@@ -0,0 +1,155 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Keys of the fields identifying the active OpenTelemetry span.
+const (
+	TraceIDKey    = "trace_id"
+	SpanIDKey     = "span_id"
+	TraceFlagsKey = "trace_flags"
+)
+
+// TraceFields returns the trace_id, span_id and trace_flags fields of the
+// OpenTelemetry span of ctx, or no field when ctx has no valid span.
+func TraceFields(ctx context.Context) []zap.Field {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+
+	return []zap.Field{
+		zap.String(TraceIDKey, sc.TraceID().String()),
+		zap.String(SpanIDKey, sc.SpanID().String()),
+		zap.String(TraceFlagsKey, sc.TraceFlags().String()),
+	}
+}
+
+// WithContext returns l with the fields identifying the OpenTelemetry span of
+// ctx, so that its logs line up with the traces. l is returned as is when ctx
+// has no valid span.
+func WithContext(ctx context.Context, l *zap.Logger) *zap.Logger {
+	fields := TraceFields(ctx)
+	if len(fields) == 0 {
+		return l
+	}
+
+	return l.With(fields...)
+}
+
+// Context returns a field carrying ctx. A core wrapped by NewTraceCore replaces
+// it by the fields identifying the span of ctx; other cores skip it.
+func Context(ctx context.Context) zap.Field {
+	return zap.Field{Key: "context", Type: zapcore.SkipType, Interface: ctx}
+}
+
+// WithTraceCore wraps the core of a logger with NewTraceCore.
+func WithTraceCore() zap.Option {
+	return zap.WrapCore(NewTraceCore)
+}
+
+// NewTraceCore wraps core to replace every field holding a context.Context,
+// such as the one of Context, by the fields identifying its OpenTelemetry span.
+func NewTraceCore(core zapcore.Core) zapcore.Core {
+	return &traceCore{Core: core}
+}
+
+// traceCore is a zapcore.Core injecting the span fields of context fields.
+type traceCore struct {
+	zapcore.Core
+}
+
+// With adds structured context to the core.
+func (c *traceCore) With(fields []zapcore.Field) zapcore.Core {
+	return &traceCore{Core: c.Core.With(traceFields(fields))}
+}
+
+// Check lets the wrapped core decide whether the entry is logged, so that its
+// level and sampling still apply, and adds it to the checked entry through a
+// checkedCore replacing the context fields.
+func (c *traceCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	checked := c.Core.Check(ent, nil)
+	if checked == nil {
+		return ce
+	}
+
+	return ce.AddCore(ent, &checkedCore{Core: c.Core, checked: checked})
+}
+
+// Write writes the entry with the span fields of its context fields.
+func (c *traceCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(ent, traceFields(fields))
+}
+
+// checkedCore writes an entry already checked by the wrapped core of a traceCore.
+type checkedCore struct {
+	zapcore.Core
+
+	checked *zapcore.CheckedEntry
+}
+
+// Write writes the checked entry with the span fields of its context fields.
+// Write errors are returned, so that the outer entry reports them to the error
+// output of its logger.
+func (c *checkedCore) Write(_ zapcore.Entry, fields []zapcore.Field) error {
+	out := &errorOutput{prefix: fmt.Sprintf("%v write error: ", c.checked.Time)}
+	c.checked.ErrorOutput = out
+	c.checked.Write(traceFields(fields)...)
+
+	return errors.Join(out.errs...)
+}
+
+// errorOutput is a zapcore.WriteSyncer collecting the write errors reported by
+// a checked entry, as lines starting with prefix.
+type errorOutput struct {
+	prefix string
+	errs   []error
+}
+
+// Write records the reported error.
+func (o *errorOutput) Write(p []byte) (int, error) {
+	msg := strings.TrimSuffix(strings.TrimPrefix(string(p), o.prefix), "\n")
+	o.errs = append(o.errs, errors.New(msg))
+
+	return len(p), nil
+}
+
+// Sync does nothing.
+func (o *errorOutput) Sync() error {
+	return nil
+}
+
+// traceFields replaces the context fields by the fields of their span.
+func traceFields(fields []zapcore.Field) []zapcore.Field {
+	var out []zapcore.Field
+
+	for i, f := range fields {
+		ctx, ok := f.Interface.(context.Context)
+		if !ok {
+			if out != nil {
+				out = append(out, f)
+			}
+			continue
+		}
+
+		if out == nil {
+			out = append(make([]zapcore.Field, 0, len(fields)+2), fields[:i]...)
+		}
+
+		out = append(out, TraceFields(ctx)...)
+	}
+
+	if out == nil {
+		return fields
+	}
+
+	return out
+}
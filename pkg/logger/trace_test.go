@@ -0,0 +1,116 @@
+package logger_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/merlindorin/go-shared/pkg/logger"
+)
+
+func spanContext() context.Context {
+	return trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{
+			0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10,
+		},
+		SpanID:     trace.SpanID{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08},
+		TraceFlags: trace.FlagsSampled,
+	}))
+}
+
+func traceFields() map[string]any {
+	return map[string]any{
+		logger.TraceIDKey:    "0102030405060708090a0b0c0d0e0f10",
+		logger.SpanIDKey:     "0102030405060708",
+		logger.TraceFlagsKey: "01",
+	}
+}
+
+// failingWriter is a zapcore.WriteSyncer failing every write.
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func (failingWriter) Sync() error { return nil }
+
+func TestWithContext(t *testing.T) {
+	t.Run("should add the span fields", func(t *testing.T) {
+		core, logs := observer.New(zapcore.InfoLevel)
+
+		logger.WithContext(spanContext(), zap.New(core)).Info("message")
+
+		assert.Equal(t, traceFields(), logs.All()[0].ContextMap())
+	})
+
+	t.Run("should return the logger as is without span", func(t *testing.T) {
+		l := zap.NewNop()
+
+		assert.Same(t, l, logger.WithContext(context.Background(), l))
+	})
+}
+
+func TestNewTraceCore(t *testing.T) {
+	t.Run("should replace context fields by the span fields", func(t *testing.T) {
+		core, logs := observer.New(zapcore.InfoLevel)
+		l := zap.New(core, logger.WithTraceCore())
+
+		l.Info("message", zap.String("key", "value"), logger.Context(spanContext()))
+		l.With(logger.Context(spanContext())).Info("with")
+
+		want := map[string]any{"key": "value"}
+		for k, v := range traceFields() {
+			want[k] = v
+		}
+
+		assert.Equal(t, want, logs.All()[0].ContextMap())
+		assert.Equal(t, traceFields(), logs.All()[1].ContextMap())
+	})
+
+	t.Run("should drop context fields without span", func(t *testing.T) {
+		core, logs := observer.New(zapcore.InfoLevel)
+
+		zap.New(core, logger.WithTraceCore()).Info("message", logger.Context(context.Background()))
+
+		assert.Empty(t, logs.All()[0].ContextMap())
+	})
+
+	t.Run("should keep the sampling of the wrapped core", func(t *testing.T) {
+		core, logs := observer.New(zapcore.InfoLevel)
+		sampled := zapcore.NewSamplerWithOptions(core, time.Minute, 100, 100)
+		l := zap.New(sampled, logger.WithTraceCore())
+
+		for range 1000 {
+			l.Info("message", logger.Context(spanContext()))
+		}
+
+		assert.Equal(t, 109, logs.Len())
+		assert.Equal(t, traceFields(), logs.All()[0].ContextMap())
+	})
+
+	t.Run("should report write errors to the error output of the logger", func(t *testing.T) {
+		var errorOutput strings.Builder
+
+		core := zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), failingWriter{}, zap.InfoLevel)
+		l := zap.New(core, logger.WithTraceCore(), zap.ErrorOutput(zapcore.AddSync(&errorOutput)))
+
+		l.Info("message", logger.Context(spanContext()))
+
+		assert.Equal(t, 1, strings.Count(errorOutput.String(), "write error: disk full"))
+	})
+
+	t.Run("should skip context fields without trace core", func(t *testing.T) {
+		core, logs := observer.New(zapcore.InfoLevel)
+
+		zap.New(core).Info("message", logger.Context(spanContext()))
+
+		assert.Empty(t, logs.All()[0].ContextMap())
+	})
+}
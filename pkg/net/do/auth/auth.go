@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/merlindorin/go-shared/pkg/net/do"
+)
+
+// Names of the handlers registered by the package.
+const (
+	AuthorizationHandler = "http_request_auth_authorization"
+	APIKeyHandler        = "http_request_auth_api_key"
+	RefreshHandler       = "http_response_auth_refresh"
+)
+
+// Token is an access token.
+type Token struct {
+	// AccessToken is the token sent to the server.
+	AccessToken string
+	// TokenType is the authorization scheme, "Bearer" when empty.
+	TokenType string
+	// Expiry is the expiration time of the token, the token never expires when zero.
+	Expiry time.Time
+}
+
+// Valid reports whether the token is set and does not expire within delta.
+func (t *Token) Valid(now time.Time, delta time.Duration) bool {
+	return t != nil && t.AccessToken != "" && (t.Expiry.IsZero() || now.Add(delta).Before(t.Expiry))
+}
+
+// Authorization returns the value of the Authorization header for the token.
+func (t *Token) Authorization() string {
+	tokenType := t.TokenType
+	if tokenType == "" || http.CanonicalHeaderKey(tokenType) == "Bearer" {
+		tokenType = "Bearer"
+	}
+
+	return tokenType + " " + t.AccessToken
+}
+
+// TokenSource provides tokens. Implementations must be safe for concurrent use.
+type TokenSource interface {
+	Token(ctx context.Context) (*Token, error)
+}
+
+// Invalidator is implemented by token sources caching their tokens. Invalidate
+// drops the cached token when it is still the rejected one, so that the next call
+// to Token fetches a new one, and keeps a token refreshed in the meantime.
+type Invalidator interface {
+	Invalidate(rejected *Token)
+}
+
+// TokenSourceFunc is an adapter to allow the use of ordinary functions as TokenSource.
+type TokenSourceFunc func(ctx context.Context) (*Token, error)
+
+// Token calls f(ctx).
+func (f TokenSourceFunc) Token(ctx context.Context) (*Token, error) {
+	return f(ctx)
+}
+
+// StaticToken returns a TokenSource always providing the given bearer token.
+func StaticToken(token string) TokenSource {
+	return TokenSourceFunc(func(_ context.Context) (*Token, error) {
+		return &Token{AccessToken: token}, nil
+	})
+}
+
+// WithBearer authenticates requests with a static bearer token.
+func WithBearer(token string) do.Option {
+	return WithTokenSource(StaticToken(token))
+}
+
+// WithBasic authenticates requests with basic authentication.
+func WithBasic(username, password string) do.Option {
+	return do.WithPreRequestHandler(
+		AuthorizationHandler,
+		func(_ context.Context, req *http.Request) error {
+			req.SetBasicAuth(username, password)
+			return nil
+		},
+	)
+}
+
+// WithAPIKeyHeader authenticates requests with an API key sent in the given header.
+func WithAPIKeyHeader(header, key string) do.Option {
+	return do.WithPreRequestHandler(
+		APIKeyHandler,
+		func(_ context.Context, req *http.Request) error {
+			req.Header.Set(header, key)
+			return nil
+		},
+	)
+}
+
+// WithAPIKeyQuery authenticates requests with an API key sent in the given query parameter.
+func WithAPIKeyQuery(param, key string) do.Option {
+	return do.WithPreRequestHandler(
+		APIKeyHandler,
+		func(_ context.Context, req *http.Request) error {
+			q := req.URL.Query()
+			q.Set(param, key)
+			req.URL.RawQuery = q.Encode()
+			return nil
+		},
+	)
+}
+
+// WithTokenSource authenticates requests with the tokens of ts. When ts is an
+// Invalidator, a 401 Unauthorized response invalidates the token and the request
+// is sent once more, through the pre-request handlers, with a new token.
+func WithTokenSource(ts TokenSource) do.Option {
+	return func(params *do.Params) {
+		var sent *Token
+
+		do.WithPreRequestHandler(
+			AuthorizationHandler,
+			func(ctx context.Context, req *http.Request) error {
+				token, err := ts.Token(ctx)
+				if err != nil {
+					return err
+				}
+
+				sent = token
+				req.Header.Set("Authorization", token.Authorization())
+				return nil
+			},
+		).Apply(params)
+
+		invalidator, ok := ts.(Invalidator)
+		if !ok {
+			params.PostRequestHandlers.Delete(RefreshHandler)
+			return
+		}
+
+		do.WithPostRequestHandler(
+			RefreshHandler,
+			func(_ context.Context, _ *http.Request, res *http.Response) error {
+				if res.StatusCode != http.StatusUnauthorized || sent == nil {
+					return nil
+				}
+
+				invalidator.Invalidate(sent)
+
+				return do.ErrResendRequest
+			},
+			do.Priority(do.PriorityStatusCheck),
+			do.Before(do.StatusCheckHandler),
+		).Apply(params)
+	}
+}
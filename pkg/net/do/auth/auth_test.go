@@ -0,0 +1,214 @@
+package auth_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/merlindorin/go-shared/pkg/must"
+	"github.com/merlindorin/go-shared/pkg/net/do"
+	"github.com/merlindorin/go-shared/pkg/net/do/auth"
+)
+
+func TestStatic(t *testing.T) {
+	tests := []struct {
+		name   string
+		option do.Option
+		check  func(t *testing.T, req *http.Request)
+	}{
+		{"bearer", auth.WithBearer("secret"), func(t *testing.T, req *http.Request) {
+			assert.Equal(t, "Bearer secret", req.Header.Get("Authorization"))
+		}},
+		{"basic", auth.WithBasic("user", "pass"), func(t *testing.T, req *http.Request) {
+			username, password, ok := req.BasicAuth()
+			assert.True(t, ok)
+			assert.Equal(t, "user", username)
+			assert.Equal(t, "pass", password)
+		}},
+		{"api key header", auth.WithAPIKeyHeader("X-Api-Key", "secret"), func(t *testing.T, req *http.Request) {
+			assert.Equal(t, "secret", req.Header.Get("X-Api-Key"))
+		}},
+		{"api key query", auth.WithAPIKeyQuery("api_key", "secret"), func(t *testing.T, req *http.Request) {
+			assert.Equal(t, "secret", req.URL.Query().Get("api_key"))
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := do.NewMockHttpClientDoer(t)
+			mockClient.EXPECT().Do(mock.Anything).RunAndReturn(func(req *http.Request) (*http.Response, error) {
+				tt.check(t, req)
+				return &http.Response{}, nil
+			}).Once()
+
+			assert.NoError(t, do.Do(context.TODO(), &url.URL{}, do.WithClient(mockClient), tt.option))
+		})
+	}
+}
+
+func TestClientCredentials(t *testing.T) {
+	var issued int
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, _ := r.BasicAuth()
+		assert.Equal(t, "client", username)
+		assert.Equal(t, "secret", password)
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+		assert.Equal(t, "read write", r.PostForm.Get("scope"))
+
+		issued++
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token": fmt.Sprintf("token-%d", issued),
+			"token_type":   "bearer",
+			"expires_in":   60,
+		})
+	}))
+	defer tokenServer.Close()
+
+	var (
+		revoked   string
+		revokeAll bool
+	)
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if revokeAll || r.Header.Get("Authorization") == revoked {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer apiServer.Close()
+
+	now := time.Now()
+	credentials := auth.NewClientCredentials(
+		must.Get(url.Parse(tokenServer.URL)),
+		"client",
+		"secret",
+		auth.WithScopes("read", "write"),
+		auth.WithNow(func() time.Time { return now }),
+	)
+	apiURL := must.Get(url.Parse(apiServer.URL))
+	send := func() error {
+		return do.Do(context.TODO(), apiURL, do.WithStatusCheck(), auth.WithTokenSource(credentials))
+	}
+
+	t.Run("should cache the token", func(t *testing.T) {
+		assert.NoError(t, send())
+		assert.NoError(t, send())
+		assert.Equal(t, 1, issued)
+	})
+
+	t.Run("should refresh the token before it expires", func(t *testing.T) {
+		now = now.Add(55 * time.Second)
+
+		assert.NoError(t, send())
+		assert.Equal(t, 2, issued)
+	})
+
+	t.Run("should fetch a new token and retry once on 401", func(t *testing.T) {
+		revoked = "Bearer token-2"
+
+		assert.NoError(t, send())
+		assert.Equal(t, 3, issued)
+	})
+
+	t.Run("should fail when the new token is refused as well", func(t *testing.T) {
+		revokeAll = true
+
+		assert.ErrorIs(t, send(), do.ErrUnauthorized)
+		assert.Equal(t, 4, issued)
+	})
+}
+
+func TestClientCredentialsInvalidate(t *testing.T) {
+	var issued int
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		issued++
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"access_token": fmt.Sprintf("token-%d", issued)})
+	}))
+	defer tokenServer.Close()
+
+	credentials := auth.NewClientCredentials(must.Get(url.Parse(tokenServer.URL)), "client", "secret")
+
+	rejected := must.Get(credentials.Token(context.TODO()))
+	credentials.Invalidate(rejected)
+	refreshed := must.Get(credentials.Token(context.TODO()))
+
+	credentials.Invalidate(rejected)
+
+	assert.Equal(t, refreshed, must.Get(credentials.Token(context.TODO())))
+	assert.Equal(t, 2, issued)
+}
+
+func TestClientCredentialsWithoutAccessToken(t *testing.T) {
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"token_type": "bearer", "expires_in": 60})
+	}))
+	defer tokenServer.Close()
+
+	credentials := auth.NewClientCredentials(must.Get(url.Parse(tokenServer.URL)), "client", "secret")
+
+	_, err := credentials.Token(context.TODO())
+	assert.ErrorIs(t, err, auth.ErrMissingAccessToken)
+}
+
+func TestWithTokenSourceResend(t *testing.T) {
+	var tokens int
+	source := &invalidatingSource{token: func() *auth.Token {
+		tokens++
+		return &auth.Token{AccessToken: fmt.Sprintf("token-%d", tokens)}
+	}}
+
+	mockClient := do.NewMockHttpClientDoer(t)
+	mockClient.EXPECT().Do(mock.Anything).RunAndReturn(func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, req.Header.Get("Authorization"), req.Header.Get("X-Signature"))
+		if req.Header.Get("Authorization") == "Bearer token-1" {
+			return &http.Response{StatusCode: http.StatusUnauthorized, Body: http.NoBody}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	}).Twice()
+
+	err := do.Do(
+		context.TODO(),
+		&url.URL{},
+		do.WithClient(mockClient),
+		do.WithStatusCheck(),
+		auth.WithTokenSource(source),
+		do.WithSigner(do.SignerFunc(func(req *http.Request) error {
+			req.Header.Set("X-Signature", req.Header.Get("Authorization"))
+			return nil
+		})),
+	)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"token-1"}, source.invalidated)
+}
+
+// invalidatingSource issues a new token once the cached one is invalidated.
+type invalidatingSource struct {
+	token       func() *auth.Token
+	cached      *auth.Token
+	invalidated []string
+}
+
+func (s *invalidatingSource) Token(context.Context) (*auth.Token, error) {
+	if s.cached == nil {
+		s.cached = s.token()
+	}
+	return s.cached, nil
+}
+
+func (s *invalidatingSource) Invalidate(rejected *auth.Token) {
+	s.invalidated = append(s.invalidated, rejected.AccessToken)
+	s.cached = nil
+}
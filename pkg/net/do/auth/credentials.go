@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/merlindorin/go-shared/pkg/net/do"
+)
+
+const defaultExpiryDelta = 10 * time.Second
+
+// ErrMissingAccessToken is returned when the token endpoint answers without an access token.
+var ErrMissingAccessToken = errors.New("token response without access_token")
+
+// ClientCredentials is a TokenSource implementing the OAuth2 client credentials
+// flow (RFC 6749, section 4.4). Tokens are cached and refreshed before they expire.
+type ClientCredentials struct {
+	tokenURL     *url.URL
+	clientID     string
+	clientSecret string
+
+	scopes       []string
+	params       url.Values
+	client       do.HTTPClientDoer
+	expiryDelta  time.Duration
+	secretInBody bool
+	now          func() time.Time
+
+	mu    sync.Mutex
+	token *Token
+}
+
+// tokenResponse is the successful response of the token endpoint.
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// NewClientCredentials creates a ClientCredentials token source for the given
+// token endpoint and client. Tokens are refreshed 10 seconds before they expire
+// unless overridden with WithExpiryDelta.
+func NewClientCredentials(
+	tokenURL *url.URL,
+	clientID, clientSecret string,
+	opts ...ClientCredentialsOption,
+) *ClientCredentials {
+	defaultOptions := []ClientCredentialsOption{
+		WithHTTPClient(http.DefaultClient),
+		WithExpiryDelta(defaultExpiryDelta),
+		WithNow(time.Now),
+	}
+
+	c := &ClientCredentials{
+		tokenURL:     tokenURL,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+	}
+
+	for _, opt := range append(defaultOptions, opts...) {
+		opt.apply(c)
+	}
+
+	return c
+}
+
+// Token returns the cached token, or fetches a new one when it is missing or about to expire.
+func (c *ClientCredentials) Token(ctx context.Context) (*Token, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token.Valid(c.now(), c.expiryDelta) {
+		return c.token, nil
+	}
+
+	token, err := c.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.token = token
+	return token, nil
+}
+
+// Invalidate drops the cached token if it is the rejected one. A token refreshed
+// by another request in the meantime is kept.
+func (c *ClientCredentials) Invalidate(rejected *Token) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != nil && rejected != nil && c.token.AccessToken == rejected.AccessToken {
+		c.token = nil
+	}
+}
+
+// fetch requests a new token from the token endpoint.
+func (c *ClientCredentials) fetch(ctx context.Context) (*Token, error) {
+	form := url.Values{}
+	for k, v := range c.params {
+		form[k] = v
+	}
+	form.Set("grant_type", "client_credentials")
+	if len(c.scopes) > 0 {
+		form.Set("scope", strings.Join(c.scopes, " "))
+	}
+
+	options := []do.Option{
+		do.WithMethod(http.MethodPost),
+		do.WithClient(c.client),
+		do.WithExtraHeader("Content-Type", do.FormContentType),
+		do.WithStatusCheck(),
+	}
+
+	if c.secretInBody {
+		form.Set("client_id", c.clientID)
+		form.Set("client_secret", c.clientSecret)
+	} else {
+		options = append(options, WithBasic(url.QueryEscape(c.clientID), url.QueryEscape(c.clientSecret)))
+	}
+
+	requestedAt := c.now()
+
+	var res tokenResponse
+	options = append(options, do.WithBody(strings.NewReader(form.Encode())), do.WithUnmarshalBody(&res))
+
+	if err := do.Do(ctx, c.tokenURL, options...); err != nil {
+		return nil, err
+	}
+
+	if res.AccessToken == "" {
+		return nil, ErrMissingAccessToken
+	}
+
+	token := &Token{AccessToken: res.AccessToken, TokenType: res.TokenType}
+	if res.ExpiresIn > 0 {
+		token.Expiry = requestedAt.Add(time.Duration(res.ExpiresIn) * time.Second)
+	}
+
+	return token, nil
+}
@@ -0,0 +1,9 @@
+// Package auth provides authentication options for do requests: static bearer
+// tokens, basic authentication, API keys sent in a header or a query parameter,
+// and tokens obtained from a TokenSource such as the OAuth2 client credentials flow.
+//
+// Example usage:
+//
+//	credentials := auth.NewClientCredentials(tokenURL, "client-id", "client-secret", auth.WithScopes("read"))
+//	client := rest.NewRest(baseURL, auth.WithTokenSource(credentials))
+package auth
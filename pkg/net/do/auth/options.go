@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/merlindorin/go-shared/pkg/net/do"
+)
+
+// ClientCredentialsOption represents a configuration setting that can be applied to ClientCredentials.
+type ClientCredentialsOption func(c *ClientCredentials)
+
+// apply sets the given ClientCredentialsOption to the ClientCredentials.
+func (o ClientCredentialsOption) apply(c *ClientCredentials) {
+	o(c)
+}
+
+// WithScopes sets the scopes requested with the token.
+func WithScopes(scopes ...string) ClientCredentialsOption {
+	return func(c *ClientCredentials) {
+		c.scopes = scopes
+	}
+}
+
+// WithEndpointParams adds parameters to the token request, such as an audience.
+func WithEndpointParams(params url.Values) ClientCredentialsOption {
+	return func(c *ClientCredentials) {
+		c.params = params
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to reach the token endpoint.
+func WithHTTPClient(client do.HTTPClientDoer) ClientCredentialsOption {
+	return func(c *ClientCredentials) {
+		c.client = client
+	}
+}
+
+// WithExpiryDelta sets how long before its expiry a token is refreshed.
+func WithExpiryDelta(d time.Duration) ClientCredentialsOption {
+	return func(c *ClientCredentials) {
+		c.expiryDelta = d
+	}
+}
+
+// WithCredentialsInBody sends the client credentials in the request body instead
+// of the Authorization header, for servers not supporting basic authentication.
+func WithCredentialsInBody() ClientCredentialsOption {
+	return func(c *ClientCredentials) {
+		c.secretInBody = true
+	}
+}
+
+// WithNow sets the time function for token expiry and testing.
+func WithNow(fn func() time.Time) ClientCredentialsOption {
+	return func(c *ClientCredentials) {
+		c.now = fn
+	}
+}
@@ -0,0 +1,44 @@
+package do
+
+import (
+	"errors"
+	"io"
+	"sync"
+)
+
+// NewCountingBody returns body counting the bytes read, which calls done with
+// their number once the body is read to the end or closed, whichever comes
+// first. It lets client decorators release resources and record the responses
+// even when a handler, such as WithUnmarshalBody, replaces the body after
+// reading it.
+func NewCountingBody(body io.ReadCloser, done func(read int64)) io.ReadCloser {
+	return &countingBody{ReadCloser: body, done: done}
+}
+
+// countingBody counts the bytes read and calls done once read or closed.
+type countingBody struct {
+	io.ReadCloser
+
+	n    int64
+	once sync.Once
+	done func(read int64)
+}
+
+// Read reads from the body, counts the bytes read and calls done at the end of the body.
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+
+	if errors.Is(err, io.EOF) {
+		b.once.Do(func() { b.done(b.n) })
+	}
+
+	return n, err
+}
+
+// Close closes the body and calls done.
+func (b *countingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() { b.done(b.n) })
+	return err
+}
@@ -0,0 +1,315 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/merlindorin/go-shared/pkg/net/do"
+)
+
+const (
+	defaultFailureRatio     = 0.5
+	defaultMinRequests      = 10
+	defaultInterval         = time.Minute
+	defaultCooldown         = 30 * time.Second
+	defaultHalfOpenRequests = 1
+)
+
+// State is the state of a circuit.
+type State int
+
+const (
+	// StateClosed lets every request through.
+	StateClosed State = iota
+	// StateOpen rejects every request with ErrCircuitOpen.
+	StateOpen
+	// StateHalfOpen lets a limited number of probe requests through.
+	StateHalfOpen
+)
+
+// String returns the name of the state.
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("State(%d)", int(s))
+	}
+}
+
+// transition is a change from a state to another.
+type transition struct {
+	from, to State
+}
+
+// Breaker is a circuit breaker. It is safe for concurrent use and is meant to
+// be shared by every request sent to the same dependency.
+type Breaker struct {
+	name             string
+	failureRatio     float64
+	minRequests      int
+	interval         time.Duration
+	cooldown         time.Duration
+	halfOpenRequests int
+	isFailure        func(res *http.Response, err error) bool
+	logger           *zap.Logger
+	now              func() time.Time
+
+	mu          sync.Mutex
+	state       State
+	expiry      time.Time
+	requests    int
+	failures    int
+	inFlight    int
+	generation  uint64
+	rejected    uint64
+	transitions map[transition]uint64
+}
+
+// New creates a new Breaker identified by name with optional configurations applied.
+// By default, the circuit opens when at least half of 10 or more requests failed
+// within a minute, stays open 30 seconds and closes after a successful probe.
+// Transport errors, 5xx and 429 responses are failures.
+func New(name string, opts ...Option) *Breaker {
+	defaultOptions := []Option{
+		WithFailureRatio(defaultFailureRatio),
+		WithMinRequests(defaultMinRequests),
+		WithInterval(defaultInterval),
+		WithCooldown(defaultCooldown),
+		WithHalfOpenRequests(defaultHalfOpenRequests),
+		WithFailureFunc(IsFailure),
+		WithLogger(zap.NewNop()),
+		WithNow(time.Now),
+	}
+
+	b := &Breaker{
+		name:        name,
+		transitions: map[transition]uint64{},
+	}
+
+	for _, opt := range append(defaultOptions, opts...) {
+		opt.apply(b)
+	}
+
+	b.expiry = b.now().Add(b.interval)
+
+	return b
+}
+
+// Name returns the name of the Breaker.
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// State returns the current state of the circuit.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.currentState(b.now())
+}
+
+// Allow reports whether a request can be sent. When it returns nil, done must
+// be called with the outcome of the request. A request cancelled through its
+// context counts neither as a success nor as a failure, it only frees its slot.
+func (b *Breaker) Allow() (func(res *http.Response, err error), error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	state := b.currentState(now)
+
+	if state == StateOpen || (state == StateHalfOpen && b.inFlight >= b.halfOpenRequests) {
+		b.rejected++
+		return nil, fmt.Errorf("%w: %s", ErrCircuitOpen, b.name)
+	}
+
+	b.inFlight++
+	generation := b.generation
+
+	var once sync.Once
+
+	return func(res *http.Response, err error) {
+		once.Do(func() {
+			if errors.Is(err, context.Canceled) {
+				b.release(generation)
+				return
+			}
+
+			b.done(generation, b.isFailure(res, err))
+		})
+	}, nil
+}
+
+// done records the outcome of a request allowed in the given generation.
+func (b *Breaker) done(generation uint64, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	state := b.currentState(now)
+
+	// The outcome of a request sent before the last state change is ignored.
+	if generation != b.generation {
+		return
+	}
+
+	b.inFlight--
+
+	switch state {
+	case StateClosed:
+		b.requests++
+		if failed {
+			b.failures++
+		}
+
+		if b.requests >= b.minRequests && float64(b.failures)/float64(b.requests) >= b.failureRatio {
+			b.setState(StateOpen, now)
+		}
+	case StateHalfOpen:
+		if failed {
+			b.setState(StateOpen, now)
+			return
+		}
+
+		b.requests++
+		if b.requests >= b.halfOpenRequests {
+			b.setState(StateClosed, now)
+		}
+	case StateOpen:
+	}
+}
+
+// release frees the slot of a request allowed in the given generation without
+// recording its outcome.
+func (b *Breaker) release(generation uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if generation == b.generation {
+		b.inFlight--
+	}
+}
+
+// currentState returns the state at now, moving an open circuit to half-open
+// after the cooldown and resetting the counts of a closed circuit every interval.
+func (b *Breaker) currentState(now time.Time) State {
+	switch b.state {
+	case StateClosed:
+		if b.interval > 0 && !now.Before(b.expiry) {
+			b.reset(now)
+		}
+	case StateOpen:
+		if !now.Before(b.expiry) {
+			b.setState(StateHalfOpen, now)
+		}
+	case StateHalfOpen:
+	}
+
+	return b.state
+}
+
+// setState moves the circuit to the given state and reports the change.
+func (b *Breaker) setState(state State, now time.Time) {
+	from := b.state
+	b.state = state
+	b.transitions[transition{from: from, to: state}]++
+	b.generation++
+	b.inFlight = 0
+	b.reset(now)
+
+	if state == StateOpen {
+		b.expiry = now.Add(b.cooldown)
+	}
+
+	b.logger.Info("circuit breaker state changed",
+		zap.String("name", b.name),
+		zap.Stringer("from", from),
+		zap.Stringer("to", state),
+	)
+}
+
+// reset clears the counts and starts a new interval.
+func (b *Breaker) reset(now time.Time) {
+	b.requests, b.failures = 0, 0
+	b.expiry = now.Add(b.interval)
+}
+
+// IsFailure is the default failure function: transport errors, 5xx and 429
+// responses are failures, context cancellation and other responses are not.
+func IsFailure(res *http.Response, err error) bool {
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return false
+		}
+
+		var httpErr *do.HTTPError
+		if errors.As(err, &httpErr) {
+			return isFailureStatus(httpErr.StatusCode)
+		}
+
+		return true
+	}
+
+	return res != nil && isFailureStatus(res.StatusCode)
+}
+
+// isFailureStatus reports whether a response status code is a failure.
+func isFailureStatus(code int) bool {
+	return code >= http.StatusInternalServerError || code == http.StatusTooManyRequests
+}
+
+// WithBreaker sends the requests through the Breaker. The outcome of a request
+// is its transport error or response, before any status check. The Breaker is
+// shared by every request using the option, including the requests of rest
+// clients and their children.
+func WithBreaker(b *Breaker) do.Option {
+	return do.WithClientDecorator(func(next do.HTTPClientDoer) do.HTTPClientDoer {
+		return &client{next: next, breaker: b}
+	})
+}
+
+// client is a do.HTTPClientDoer sending requests through a Breaker.
+type client struct {
+	next    do.HTTPClientDoer
+	breaker *Breaker
+}
+
+// Do sends the request unless the circuit is open.
+func (c *client) Do(req *http.Request) (*http.Response, error) {
+	done, err := c.breaker.Allow()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.next.Do(req)
+	done(res, err)
+
+	return res, err
+}
+
+// Decorate returns a do.Doer sending requests through next unless the circuit
+// is open. The outcome of a request is the error returned by next.
+func Decorate(b *Breaker, next do.Doer) do.Doer {
+	return do.D(func(ctx context.Context, options ...do.Option) error {
+		done, err := b.Allow()
+		if err != nil {
+			return err
+		}
+
+		err = next.Do(ctx, options...)
+		done(nil, err)
+
+		return err
+	})
+}
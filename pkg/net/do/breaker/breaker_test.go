@@ -0,0 +1,161 @@
+package breaker_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/merlindorin/go-shared/pkg/must"
+	"github.com/merlindorin/go-shared/pkg/net/do"
+	"github.com/merlindorin/go-shared/pkg/net/do/breaker"
+)
+
+func response(code int) *http.Response {
+	return &http.Response{StatusCode: code, Body: io.NopCloser(strings.NewReader(""))}
+}
+
+func TestBreaker(t *testing.T) {
+	u := must.Get(url.Parse("http://localhost"))
+
+	t.Run("should open after failures, then close after a successful probe", func(t *testing.T) {
+		now := time.Now()
+		b := breaker.New("api",
+			breaker.WithMinRequests(2),
+			breaker.WithCooldown(time.Minute),
+			breaker.WithNow(func() time.Time { return now }),
+		)
+
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).Return(response(http.StatusServiceUnavailable), nil).Twice()
+
+		for range 2 {
+			assert.NoError(t, do.Do(t.Context(), u, do.WithClient(mockClient), breaker.WithBreaker(b)))
+		}
+
+		assert.Equal(t, breaker.StateOpen, b.State())
+		assert.ErrorIs(t, do.Do(t.Context(), u, do.WithClient(mockClient), breaker.WithBreaker(b)), breaker.ErrCircuitOpen)
+
+		now = now.Add(time.Minute)
+		assert.Equal(t, breaker.StateHalfOpen, b.State())
+
+		mockClient.EXPECT().Do(mock.Anything).Return(response(http.StatusOK), nil).Once()
+
+		assert.NoError(t, do.Do(t.Context(), u, do.WithClient(mockClient), breaker.WithBreaker(b)))
+		assert.Equal(t, breaker.StateClosed, b.State())
+	})
+
+	t.Run("should reopen when a probe fails", func(t *testing.T) {
+		now := time.Now()
+		b := breaker.New("api",
+			breaker.WithMinRequests(1),
+			breaker.WithNow(func() time.Time { return now }),
+		)
+
+		failing := do.D(func(context.Context, ...do.Option) error { return errors.New("connection refused") })
+		d := breaker.Decorate(b, failing)
+
+		assert.Error(t, d.Do(t.Context()))
+		assert.Equal(t, breaker.StateOpen, b.State())
+
+		now = now.Add(time.Hour)
+
+		assert.NotErrorIs(t, d.Do(t.Context()), breaker.ErrCircuitOpen)
+		assert.ErrorIs(t, d.Do(t.Context()), breaker.ErrCircuitOpen)
+	})
+
+	t.Run("should ignore client errors", func(t *testing.T) {
+		b := breaker.New("api", breaker.WithMinRequests(1))
+
+		notFound := do.D(func(context.Context, ...do.Option) error {
+			return &do.HTTPError{StatusCode: http.StatusNotFound}
+		})
+
+		assert.ErrorIs(t, breaker.Decorate(b, notFound).Do(t.Context()), do.ErrNotFound)
+		assert.Equal(t, breaker.StateClosed, b.State())
+	})
+
+	t.Run("should let a limited number of probes through a half-open circuit", func(t *testing.T) {
+		now := time.Now()
+		b := breaker.New("api",
+			breaker.WithMinRequests(1),
+			breaker.WithNow(func() time.Time { return now }),
+		)
+
+		done, err := b.Allow()
+		assert.NoError(t, err)
+		done(response(http.StatusInternalServerError), nil)
+
+		now = now.Add(time.Hour)
+
+		done, err = b.Allow()
+		assert.NoError(t, err)
+
+		_, err = b.Allow()
+		assert.ErrorIs(t, err, breaker.ErrCircuitOpen)
+
+		done(response(http.StatusOK), nil)
+		assert.Equal(t, breaker.StateClosed, b.State())
+	})
+
+	t.Run("should neither close nor reopen when a probe is cancelled", func(t *testing.T) {
+		now := time.Now()
+		b := breaker.New("api",
+			breaker.WithMinRequests(1),
+			breaker.WithNow(func() time.Time { return now }),
+		)
+
+		done, err := b.Allow()
+		assert.NoError(t, err)
+		done(response(http.StatusInternalServerError), nil)
+
+		now = now.Add(time.Hour)
+
+		done, err = b.Allow()
+		assert.NoError(t, err)
+		done(nil, context.Canceled)
+
+		assert.Equal(t, breaker.StateHalfOpen, b.State())
+
+		done, err = b.Allow()
+		assert.NoError(t, err)
+		done(nil, errors.New("connection refused"))
+
+		assert.Equal(t, breaker.StateOpen, b.State())
+	})
+}
+
+func TestCollector(t *testing.T) {
+	b := breaker.New("api", breaker.WithMinRequests(1))
+
+	done, err := b.Allow()
+	assert.NoError(t, err)
+	done(nil, errors.New("connection refused"))
+
+	_, err = b.Allow()
+	assert.ErrorIs(t, err, breaker.ErrCircuitOpen)
+
+	want := `
+# HELP circuit_breaker_rejected_requests_total Number of requests rejected by an open circuit breaker.
+# TYPE circuit_breaker_rejected_requests_total counter
+circuit_breaker_rejected_requests_total{name="api"} 1
+# HELP circuit_breaker_state State of the circuit breaker, 1 for the current state.
+# TYPE circuit_breaker_state gauge
+circuit_breaker_state{name="api",state="closed"} 0
+circuit_breaker_state{name="api",state="half-open"} 0
+circuit_breaker_state{name="api",state="open"} 1
+# HELP circuit_breaker_state_changes_total Number of state changes of the circuit breaker.
+# TYPE circuit_breaker_state_changes_total counter
+circuit_breaker_state_changes_total{from="closed",name="api",to="open"} 1
+`
+
+	assert.NoError(t, testutil.CollectAndCompare(breaker.NewCollector(b), strings.NewReader(want)))
+}
@@ -0,0 +1,75 @@
+package breaker
+
+import (
+	"maps"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector exposes the state of breakers to Prometheus.
+type Collector struct {
+	breakers []*Breaker
+
+	stateDesc       *prometheus.Desc
+	transitionsDesc *prometheus.Desc
+	rejectedDesc    *prometheus.Desc
+}
+
+// NewCollector creates a Collector for the given breakers.
+func NewCollector(breakers ...*Breaker) *Collector {
+	return &Collector{
+		breakers: breakers,
+		stateDesc: prometheus.NewDesc(
+			"circuit_breaker_state",
+			"State of the circuit breaker, 1 for the current state.",
+			[]string{"name", "state"},
+			nil,
+		),
+		transitionsDesc: prometheus.NewDesc(
+			"circuit_breaker_state_changes_total",
+			"Number of state changes of the circuit breaker.",
+			[]string{"name", "from", "to"},
+			nil,
+		),
+		rejectedDesc: prometheus.NewDesc(
+			"circuit_breaker_rejected_requests_total",
+			"Number of requests rejected by an open circuit breaker.",
+			[]string{"name"},
+			nil,
+		),
+	}
+}
+
+// Describe returns all descriptions of the collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.stateDesc
+	ch <- c.transitionsDesc
+	ch <- c.rejectedDesc
+}
+
+// Collect returns the current state of all metrics of the collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	for _, b := range c.breakers {
+		b.mu.Lock()
+		current := b.currentState(b.now())
+		transitions := maps.Clone(b.transitions)
+		rejected := b.rejected
+		b.mu.Unlock()
+
+		for _, state := range []State{StateClosed, StateOpen, StateHalfOpen} {
+			value := 0.0
+			if state == current {
+				value = 1
+			}
+			ch <- prometheus.MustNewConstMetric(c.stateDesc, prometheus.GaugeValue, value, b.name, state.String())
+		}
+
+		for t, n := range transitions {
+			ch <- prometheus.MustNewConstMetric(
+				c.transitionsDesc, prometheus.CounterValue, float64(n), b.name, t.from.String(), t.to.String(),
+			)
+		}
+
+		ch <- prometheus.MustNewConstMetric(c.rejectedDesc, prometheus.CounterValue, float64(rejected), b.name)
+	}
+}
@@ -0,0 +1,10 @@
+// Package breaker provides a circuit breaker for do requests. A Breaker stops
+// sending requests to a failing dependency: once the ratio of failed requests
+// reaches a threshold the circuit opens and requests fail fast with
+// ErrCircuitOpen. After a cooldown the circuit is half-open and lets a few
+// probe requests through, closing again when they succeed.
+//
+// A Breaker is installed on a single request or a rest client with
+// WithBreaker, or wraps any do.Doer with Decorate. State changes are logged and
+// exposed to Prometheus through a Collector.
+package breaker
@@ -0,0 +1,6 @@
+package breaker
+
+import "errors"
+
+// ErrCircuitOpen is returned when a request is rejected because the circuit is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
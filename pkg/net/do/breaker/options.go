@@ -0,0 +1,75 @@
+package breaker
+
+import (
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Option represents a configuration setting that can be applied to a Breaker.
+type Option func(b *Breaker)
+
+// apply sets the given Option to the Breaker.
+func (o Option) apply(b *Breaker) {
+	o(b)
+}
+
+// WithFailureRatio sets the ratio of failed requests, between 0 and 1, that opens the circuit.
+func WithFailureRatio(ratio float64) Option {
+	return func(b *Breaker) {
+		b.failureRatio = min(max(ratio, 0), 1)
+	}
+}
+
+// WithMinRequests sets the number of requests required in an interval before the
+// failure ratio is evaluated.
+func WithMinRequests(n int) Option {
+	return func(b *Breaker) {
+		b.minRequests = max(n, 1)
+	}
+}
+
+// WithInterval sets the period after which the counts of a closed circuit are reset.
+func WithInterval(d time.Duration) Option {
+	return func(b *Breaker) {
+		b.interval = d
+	}
+}
+
+// WithCooldown sets how long the circuit stays open before becoming half-open.
+func WithCooldown(d time.Duration) Option {
+	return func(b *Breaker) {
+		b.cooldown = d
+	}
+}
+
+// WithHalfOpenRequests sets the number of probe requests let through a
+// half-open circuit. The circuit closes once all of them succeed.
+func WithHalfOpenRequests(n int) Option {
+	return func(b *Breaker) {
+		b.halfOpenRequests = max(n, 1)
+	}
+}
+
+// WithFailureFunc sets the function deciding whether a request failed. The
+// response is nil when the breaker decorates a do.Doer.
+func WithFailureFunc(f func(res *http.Response, err error) bool) Option {
+	return func(b *Breaker) {
+		b.isFailure = f
+	}
+}
+
+// WithLogger sets the logger used to report state changes.
+func WithLogger(logger *zap.Logger) Option {
+	return func(b *Breaker) {
+		b.logger = logger
+	}
+}
+
+// WithNow sets the clock of the Breaker.
+func WithNow(now func() time.Time) Option {
+	return func(b *Breaker) {
+		b.now = now
+	}
+}
@@ -0,0 +1,372 @@
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/merlindorin/go-shared/pkg/net/do"
+)
+
+// DefaultMaxEntrySize is the default maximum size of the response bodies stored by a Cache.
+const DefaultMaxEntrySize = 10 << 20
+
+// Cache is a private HTTP cache. It is safe for concurrent use and is meant to
+// be shared by every request of a client.
+type Cache struct {
+	name         string
+	store        Store
+	maxEntrySize int64
+	logger       *zap.Logger
+	now          func() time.Time
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// New creates a new Cache identified by name, keeping its entries in store.
+func New(name string, store Store, opts ...Option) *Cache {
+	defaultOptions := []Option{
+		WithMaxEntrySize(DefaultMaxEntrySize),
+		WithLogger(zap.NewNop()),
+		WithNow(time.Now),
+	}
+
+	c := &Cache{
+		name:  name,
+		store: store,
+	}
+
+	for _, opt := range append(defaultOptions, opts...) {
+		opt.apply(c)
+	}
+
+	return c
+}
+
+// Name returns the name of the Cache.
+func (c *Cache) Name() string {
+	return c.name
+}
+
+// Client returns a do.HTTPClientDoer answering requests from the cache and
+// sending the others through next.
+func (c *Cache) Client(next do.HTTPClientDoer) do.HTTPClientDoer {
+	return &client{next: next, cache: c}
+}
+
+// WithCache answers requests from the Cache when possible. The Cache is shared
+// by every request using the option, including the requests of rest clients
+// and their children.
+func WithCache(c *Cache) do.Option {
+	return do.WithClientDecorator(c.Client)
+}
+
+// entry is a stored response.
+type entry struct {
+	Response     []byte
+	Vary         http.Header
+	RequestTime  time.Time
+	ResponseTime time.Time
+}
+
+// response decodes the stored response for req.
+func (e *entry) response(req *http.Request) (*http.Response, error) {
+	return http.ReadResponse(bufio.NewReader(bytes.NewReader(e.Response)), req)
+}
+
+// matches reports whether the headers selected by the Vary header of the stored
+// response are the same in req.
+func (e *entry) matches(req *http.Request) bool {
+	for name, values := range e.Vary {
+		if !slices.Equal(req.Header.Values(name), values) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// client is a do.HTTPClientDoer backed by a Cache.
+type client struct {
+	next  do.HTTPClientDoer
+	cache *Cache
+}
+
+// Do answers the request from the cache when possible.
+func (cl *client) Do(req *http.Request) (*http.Response, error) {
+	c := cl.cache
+
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		res, err := cl.next.Do(req)
+		if err == nil && !isSafe(req.Method) && res.StatusCode < http.StatusBadRequest {
+			c.delete(cacheKey(http.MethodGet, req))
+			c.delete(cacheKey(http.MethodHead, req))
+		}
+		return res, err
+	}
+
+	if isConditional(req) || req.Header.Get("Range") != "" {
+		return cl.next.Do(req)
+	}
+
+	key := cacheKey(req.Method, req)
+
+	e, cached, stored := c.load(key, req)
+	if cached != nil {
+		now := c.now()
+		age := currentAge(cached, e.RequestTime, e.ResponseTime, now)
+
+		if fresh(req, cached, age, freshnessLifetime(cached, e.ResponseTime)) {
+			c.hits.Add(1)
+			cached.Header.Set("Age", strconv.FormatInt(int64(age/time.Second), 10))
+			return cached, nil
+		}
+	}
+
+	if parseCacheControl(req.Header).has("only-if-cached") {
+		c.misses.Add(1)
+		return gatewayTimeout(req), nil
+	}
+
+	if cached != nil {
+		return cl.revalidate(req, key, e, cached)
+	}
+
+	c.misses.Add(1)
+
+	requestTime := c.now()
+	res, err := cl.next.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if !storable(req, res) {
+		if stored {
+			c.delete(key)
+		}
+		return res, nil
+	}
+
+	return c.save(key, req, res, requestTime)
+}
+
+// revalidate sends a conditional request for a stale entry and serves the stored
+// response when the origin answers 304 Not Modified.
+func (cl *client) revalidate(req *http.Request, key string, e *entry, cached *http.Response) (*http.Response, error) {
+	c := cl.cache
+
+	conditional := req.Clone(req.Context())
+	if etag := cached.Header.Get("ETag"); etag != "" {
+		conditional.Header.Set("If-None-Match", etag)
+	}
+	if lastModified := cached.Header.Get("Last-Modified"); lastModified != "" {
+		conditional.Header.Set("If-Modified-Since", lastModified)
+	}
+
+	requestTime := c.now()
+	res, err := cl.next.Do(conditional)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusNotModified {
+		c.misses.Add(1)
+		if !storable(req, res) {
+			c.delete(key)
+			return res, nil
+		}
+		return c.save(key, req, res, requestTime)
+	}
+
+	c.hits.Add(1)
+	_, _ = io.Copy(io.Discard, res.Body)
+	_ = res.Body.Close()
+
+	for name, values := range res.Header {
+		if name != "Content-Length" {
+			cached.Header[name] = values
+		}
+	}
+
+	return c.save(key, req, cached, requestTime)
+}
+
+// load returns the entry stored for key and its decoded response if it matches req.
+func (c *Cache) load(key string, req *http.Request) (*entry, *http.Response, bool) {
+	raw, ok, err := c.store.Get(key)
+	if err != nil {
+		c.logger.Warn("cannot load cache entry", zap.String("key", key), zap.Error(err))
+	}
+	if !ok {
+		return nil, nil, false
+	}
+
+	e := &entry{}
+	if err = gob.NewDecoder(bytes.NewReader(raw)).Decode(e); err != nil {
+		c.logger.Warn("cannot decode cache entry", zap.String("key", key), zap.Error(err))
+		return nil, nil, true
+	}
+
+	if !e.matches(req) {
+		return nil, nil, true
+	}
+
+	res, err := e.response(req)
+	if err != nil {
+		c.logger.Warn("cannot decode cached response", zap.String("key", key), zap.Error(err))
+		return nil, nil, true
+	}
+
+	return e, res, true
+}
+
+// save stores the response and returns it with a replayed body. Responses larger
+// than the maximum entry size are returned as they are, without being stored.
+func (c *Cache) save(key string, req *http.Request, res *http.Response, requestTime time.Time) (*http.Response, error) {
+	if c.maxEntrySize > 0 && res.ContentLength > c.maxEntrySize {
+		c.delete(key)
+		return res, nil
+	}
+
+	body, err := c.readBody(res)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.maxEntrySize > 0 && int64(len(body)) > c.maxEntrySize {
+		c.delete(key)
+		res.Body = &readCloser{Reader: io.MultiReader(bytes.NewReader(body), res.Body), Closer: res.Body}
+		return res, nil
+	}
+
+	_ = res.Body.Close()
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	if res.ProtoMajor == 0 {
+		res.Proto, res.ProtoMajor, res.ProtoMinor = "HTTP/1.1", 1, 1
+	}
+
+	raw, err := httputil.DumpResponse(res, true)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read response: %w", err)
+	}
+
+	e := &entry{
+		Response:     raw,
+		Vary:         http.Header{},
+		RequestTime:  requestTime,
+		ResponseTime: c.now(),
+	}
+
+	for _, v := range res.Header.Values("Vary") {
+		for field := range strings.SplitSeq(v, ",") {
+			if field = strings.TrimSpace(field); field != "" {
+				e.Vary[http.CanonicalHeaderKey(field)] = req.Header.Values(field)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err = gob.NewEncoder(&buf).Encode(e); err == nil {
+		err = c.store.Set(key, buf.Bytes())
+	}
+	if err != nil {
+		c.logger.Warn("cannot store cache entry", zap.String("key", key), zap.Error(err))
+	}
+
+	return e.response(req)
+}
+
+// readBody reads the body of res up to one byte past the maximum entry size, so
+// that larger bodies are detected without being read in full.
+func (c *Cache) readBody(res *http.Response) ([]byte, error) {
+	r := io.Reader(res.Body)
+	if c.maxEntrySize > 0 {
+		r = io.LimitReader(r, c.maxEntrySize+1)
+	}
+
+	body, err := io.ReadAll(r)
+	if err != nil {
+		_ = res.Body.Close()
+		return nil, fmt.Errorf("cannot read response: %w", err)
+	}
+
+	return body, nil
+}
+
+// readCloser is a response body reading from Reader and closing Closer.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// delete removes the entry stored for key.
+func (c *Cache) delete(key string) {
+	if err := c.store.Delete(key); err != nil {
+		c.logger.Warn("cannot delete cache entry", zap.String("key", key), zap.Error(err))
+	}
+}
+
+// cacheKey returns the key of the response to a request with the given method and
+// the URL of req. Requests with credentials are keyed on a hash of their
+// Authorization header, so that a response is only served to the credentials it
+// was sent to.
+func cacheKey(method string, req *http.Request) string {
+	key := method + " " + req.URL.String()
+
+	if authorization := req.Header.Get("Authorization"); authorization != "" {
+		sum := sha256.Sum256([]byte(authorization))
+		key += " " + hex.EncodeToString(sum[:])
+	}
+
+	return key
+}
+
+// isSafe reports whether the method is safe as defined by RFC 9110.
+func isSafe(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	default:
+		return false
+	}
+}
+
+// isConditional reports whether the request carries its own preconditions.
+func isConditional(req *http.Request) bool {
+	for _, name := range []string{"If-None-Match", "If-Modified-Since", "If-Match", "If-Unmodified-Since", "If-Range"} {
+		if req.Header.Get(name) != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// gatewayTimeout is the response to an only-if-cached request missing from the cache.
+func gatewayTimeout(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:     "504 Gateway Timeout",
+		StatusCode: http.StatusGatewayTimeout,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{},
+		Body:       http.NoBody,
+		Request:    req,
+	}
+}
@@ -0,0 +1,298 @@
+package cache_test
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/merlindorin/go-shared/pkg/must"
+	"github.com/merlindorin/go-shared/pkg/net/do"
+	"github.com/merlindorin/go-shared/pkg/net/do/cache"
+)
+
+func response(code int, body string, header http.Header) *http.Response {
+	return &http.Response{StatusCode: code, Header: header, Body: io.NopCloser(strings.NewReader(body))}
+}
+
+func get(t *testing.T, client do.HTTPClientDoer, header http.Header) (*http.Response, string) {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "http://localhost/items", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header != nil {
+		req.Header = header
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return res, string(body)
+}
+
+func TestCache(t *testing.T) {
+	t.Run("should serve fresh responses from the cache", func(t *testing.T) {
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).Return(
+			response(http.StatusOK, "items", http.Header{"Cache-Control": {"max-age=60"}}), nil,
+		).Once()
+
+		client := cache.New("api", cache.NewMemoryStore(1<<20)).Client(mockClient)
+
+		_, body := get(t, client, nil)
+		assert.Equal(t, "items", body)
+
+		res, body := get(t, client, nil)
+		assert.Equal(t, "items", body)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "0", res.Header.Get("Age"))
+	})
+
+	t.Run("should revalidate stale responses", func(t *testing.T) {
+		now := time.Now()
+
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).Return(
+			response(http.StatusOK, "items", http.Header{"Cache-Control": {"max-age=60"}, "Etag": {`"v1"`}}), nil,
+		).Once()
+
+		c := cache.New("api", cache.NewMemoryStore(1<<20), cache.WithNow(func() time.Time { return now }))
+		client := c.Client(mockClient)
+		get(t, client, nil)
+
+		now = now.Add(time.Minute)
+
+		mockClient.EXPECT().Do(mock.MatchedBy(func(req *http.Request) bool {
+			return req.Header.Get("If-None-Match") == `"v1"`
+		})).Return(response(http.StatusNotModified, "", http.Header{"Cache-Control": {"max-age=60"}}), nil).Once()
+
+		res, body := get(t, client, nil)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "items", body)
+
+		_, body = get(t, client, nil)
+		assert.Equal(t, "items", body)
+	})
+
+	t.Run("should honour request directives", func(t *testing.T) {
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).RunAndReturn(func(*http.Request) (*http.Response, error) {
+			return response(http.StatusOK, "items", http.Header{"Cache-Control": {"max-age=60"}}), nil
+		}).Twice()
+
+		client := cache.New("api", cache.NewMemoryStore(1<<20)).Client(mockClient)
+		get(t, client, nil)
+		get(t, client, http.Header{"Cache-Control": {"no-cache"}})
+	})
+
+	t.Run("should not store no-store responses", func(t *testing.T) {
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).RunAndReturn(func(*http.Request) (*http.Response, error) {
+			return response(http.StatusOK, "items", http.Header{"Cache-Control": {"no-store"}}), nil
+		}).Twice()
+
+		store := cache.NewMemoryStore(1 << 20)
+		client := cache.New("api", store).Client(mockClient)
+		get(t, client, nil)
+		get(t, client, nil)
+
+		assert.Zero(t, store.Len())
+	})
+
+	t.Run("should not store responses larger than the maximum entry size", func(t *testing.T) {
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).RunAndReturn(func(*http.Request) (*http.Response, error) {
+			return response(http.StatusOK, "large items", http.Header{"Cache-Control": {"max-age=60"}}), nil
+		}).Twice()
+		mockClient.EXPECT().Do(mock.Anything).RunAndReturn(func(*http.Request) (*http.Response, error) {
+			res := response(http.StatusOK, "large items", http.Header{"Cache-Control": {"max-age=60"}})
+			res.ContentLength = int64(len("large items"))
+			return res, nil
+		}).Once()
+
+		store := cache.NewMemoryStore(1 << 20)
+		client := cache.New("api", store, cache.WithMaxEntrySize(5)).Client(mockClient)
+
+		for range 3 {
+			_, body := get(t, client, nil)
+			assert.Equal(t, "large items", body)
+		}
+
+		assert.Zero(t, store.Len())
+	})
+
+	t.Run("should not store responses without freshness nor validator", func(t *testing.T) {
+		pr, pw := io.Pipe()
+		defer pw.Close()
+
+		go func() { _, _ = pw.Write([]byte("data: event\n\n")) }()
+
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"text/event-stream"}},
+			Body:       pr,
+		}, nil).Once()
+
+		store := cache.NewMemoryStore(1 << 20)
+		client := cache.New("api", store).Client(mockClient)
+
+		req := must.Get(http.NewRequestWithContext(t.Context(), http.MethodGet, "http://localhost/events", nil))
+		res := must.Get(client.Do(req))
+		defer res.Body.Close()
+
+		event := make([]byte, len("data: event\n\n"))
+		_, err := io.ReadFull(res.Body, event)
+		assert.NoError(t, err)
+		assert.Equal(t, "data: event\n\n", string(event))
+		assert.Zero(t, store.Len())
+	})
+
+	t.Run("should key the entries of authorized requests on their credentials", func(t *testing.T) {
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).RunAndReturn(func(req *http.Request) (*http.Response, error) {
+			return response(http.StatusOK, req.Header.Get("Authorization"), http.Header{
+				"Cache-Control": {"private, max-age=60"},
+			}), nil
+		}).Twice()
+
+		client := cache.New("api", cache.NewMemoryStore(1<<20)).Client(mockClient)
+
+		_, body := get(t, client, http.Header{"Authorization": {"Bearer alice"}})
+		assert.Equal(t, "Bearer alice", body)
+
+		_, body = get(t, client, http.Header{"Authorization": {"Bearer bob"}})
+		assert.Equal(t, "Bearer bob", body)
+
+		_, body = get(t, client, http.Header{"Authorization": {"Bearer alice"}})
+		assert.Equal(t, "Bearer alice", body)
+	})
+
+	t.Run("should answer only-if-cached requests missing from the cache with 504", func(t *testing.T) {
+		client := cache.New("api", cache.NewMemoryStore(1<<20)).Client(do.NewMockHttpClientDoer(t))
+
+		res, _ := get(t, client, http.Header{"Cache-Control": {"only-if-cached"}})
+		assert.Equal(t, http.StatusGatewayTimeout, res.StatusCode)
+	})
+
+	t.Run("should select the stored response with the Vary header", func(t *testing.T) {
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).RunAndReturn(func(req *http.Request) (*http.Response, error) {
+			return response(http.StatusOK, req.Header.Get("Accept"), http.Header{
+				"Cache-Control": {"max-age=60"},
+				"Vary":          {"Accept"},
+			}), nil
+		}).Twice()
+
+		client := cache.New("api", cache.NewMemoryStore(1<<20)).Client(mockClient)
+
+		_, body := get(t, client, http.Header{"Accept": {"application/json"}})
+		assert.Equal(t, "application/json", body)
+
+		_, body = get(t, client, http.Header{"Accept": {"application/xml"}})
+		assert.Equal(t, "application/xml", body)
+	})
+
+	t.Run("should invalidate the entry on unsafe requests", func(t *testing.T) {
+		u := "http://localhost/items"
+
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).RunAndReturn(func(*http.Request) (*http.Response, error) {
+			return response(http.StatusOK, "items", http.Header{"Cache-Control": {"max-age=60"}}), nil
+		}).Times(3)
+
+		c := cache.New("api", cache.NewMemoryStore(1<<20))
+		get(t, c.Client(mockClient), nil)
+
+		err := do.Do(t.Context(), must.Get(url.Parse(u)),
+			do.WithClient(mockClient),
+			cache.WithCache(c),
+			do.WithMethod(http.MethodPost),
+		)
+		assert.NoError(t, err)
+
+		get(t, c.Client(mockClient), nil)
+	})
+
+	t.Run("should expose hits and misses", func(t *testing.T) {
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).Return(
+			response(http.StatusOK, "items", http.Header{"Cache-Control": {"max-age=60"}}), nil,
+		).Once()
+
+		c := cache.New("api", cache.NewMemoryStore(1<<20))
+		get(t, c.Client(mockClient), nil)
+		get(t, c.Client(mockClient), nil)
+		get(t, c.Client(mockClient), nil)
+
+		want := `
+# HELP http_cache_hits_total Number of requests answered from the HTTP cache, including revalidated responses.
+# TYPE http_cache_hits_total counter
+http_cache_hits_total{name="api"} 2
+# HELP http_cache_misses_total Number of cacheable requests answered by the origin.
+# TYPE http_cache_misses_total counter
+http_cache_misses_total{name="api"} 1
+`
+
+		assert.NoError(t, testutil.CollectAndCompare(cache.NewCollector(c), strings.NewReader(want)))
+	})
+}
+
+func TestMemoryStore(t *testing.T) {
+	store := cache.NewMemoryStore(10)
+
+	assert.NoError(t, store.Set("a", []byte("12345")))
+	assert.NoError(t, store.Set("b", []byte("12345")))
+
+	_, ok, _ := store.Get("a")
+	assert.True(t, ok)
+
+	assert.NoError(t, store.Set("c", []byte("12345")))
+
+	_, ok, _ = store.Get("b")
+	assert.False(t, ok, "least recently used entry should be evicted")
+
+	_, ok, _ = store.Get("a")
+	assert.True(t, ok)
+
+	assert.NoError(t, store.Set("d", []byte("12345678901")))
+	_, ok, _ = store.Get("d")
+	assert.False(t, ok, "entries larger than the bound should not be stored")
+}
+
+func TestDiskStore(t *testing.T) {
+	store, err := cache.NewDiskStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NoError(t, store.Set("GET http://localhost/items", []byte("items")))
+
+	value, ok, err := store.Get("GET http://localhost/items")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("items"), value)
+
+	assert.NoError(t, store.Delete("GET http://localhost/items"))
+	assert.NoError(t, store.Delete("GET http://localhost/items"))
+
+	_, ok, err = store.Get("GET http://localhost/items")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
@@ -0,0 +1,46 @@
+package cache
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector exposes the hits and misses of caches to Prometheus.
+type Collector struct {
+	caches []*Cache
+
+	hitsDesc   *prometheus.Desc
+	missesDesc *prometheus.Desc
+}
+
+// NewCollector creates a Collector for the given caches.
+func NewCollector(caches ...*Cache) *Collector {
+	return &Collector{
+		caches: caches,
+		hitsDesc: prometheus.NewDesc(
+			"http_cache_hits_total",
+			"Number of requests answered from the HTTP cache, including revalidated responses.",
+			[]string{"name"},
+			nil,
+		),
+		missesDesc: prometheus.NewDesc(
+			"http_cache_misses_total",
+			"Number of cacheable requests answered by the origin.",
+			[]string{"name"},
+			nil,
+		),
+	}
+}
+
+// Describe returns all descriptions of the collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hitsDesc
+	ch <- c.missesDesc
+}
+
+// Collect returns the current state of all metrics of the collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	for _, cache := range c.caches {
+		ch <- prometheus.MustNewConstMetric(c.hitsDesc, prometheus.CounterValue, float64(cache.hits.Load()), cache.name)
+		ch <- prometheus.MustNewConstMetric(c.missesDesc, prometheus.CounterValue, float64(cache.misses.Load()), cache.name)
+	}
+}
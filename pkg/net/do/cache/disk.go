@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// DiskStore is a Store keeping every entry in its own file of a directory.
+type DiskStore struct {
+	dir string
+}
+
+// NewDiskStore creates a DiskStore in dir, creating the directory if needed.
+func NewDiskStore(dir string) (*DiskStore, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("cannot create cache directory: %w", err)
+	}
+
+	return &DiskStore{dir: dir}, nil
+}
+
+// Get returns the value stored for key.
+func (s *DiskStore) Get(key string) ([]byte, bool, error) {
+	value, err := os.ReadFile(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("cannot read cache entry: %w", err)
+	}
+
+	return value, true, nil
+}
+
+// Set stores value for key. The file is replaced atomically so concurrent
+// readers never see a partial entry.
+func (s *DiskStore) Set(key string, value []byte) error {
+	f, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("cannot create cache entry: %w", err)
+	}
+
+	_, err = f.Write(value)
+	err = errors.Join(err, f.Close())
+	if err == nil {
+		err = os.Rename(f.Name(), s.path(key))
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return fmt.Errorf("cannot write cache entry: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the value stored for key.
+func (s *DiskStore) Delete(key string) error {
+	if err := os.Remove(s.path(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("cannot delete cache entry: %w", err)
+	}
+
+	return nil
+}
+
+// path returns the file of the entry of key.
+func (s *DiskStore) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:]))
+}
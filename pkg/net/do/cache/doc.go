@@ -0,0 +1,15 @@
+// Package cache provides a private HTTP cache for do requests following the
+// freshness and validation rules of RFC 9111.
+//
+// A Cache keeps GET and HEAD responses in a pluggable Store, serves them while
+// they are fresh and revalidates stale entries with If-None-Match and
+// If-Modified-Since, reusing the stored response on 304 Not Modified. Responses
+// with neither freshness nor validator, such as streams, are never stored, and
+// requests with an Authorization header only share entries with the same
+// credentials. Requests with unsafe methods invalidate the entry of their URL. The package ships an
+// in-memory LRU store bounded in bytes and an on-disk store.
+//
+// A Cache is installed on a request or a rest client with WithCache, or wraps
+// any do.HTTPClientDoer with Cache.Client so it can be set with do.WithClient.
+// Hits and misses are exposed to Prometheus through a Collector.
+package cache
@@ -0,0 +1,184 @@
+package cache
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// heuristicFraction is the fraction of the time since the last modification used
+// as freshness lifetime when a response has no explicit expiration.
+const heuristicFraction = 10
+
+// cacheControl holds the directives of a Cache-Control header.
+type cacheControl map[string]string
+
+// parseCacheControl parses the Cache-Control directives of h.
+func parseCacheControl(h http.Header) cacheControl {
+	cc := cacheControl{}
+
+	for _, v := range h.Values("Cache-Control") {
+		for _, directive := range strings.Split(v, ",") {
+			name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			if name == "" {
+				continue
+			}
+			cc[strings.ToLower(name)] = strings.Trim(value, `"`)
+		}
+	}
+
+	return cc
+}
+
+// has reports whether the directive is present.
+func (cc cacheControl) has(name string) bool {
+	_, ok := cc[name]
+	return ok
+}
+
+// seconds returns the delta-seconds value of the directive.
+func (cc cacheControl) seconds(name string) (time.Duration, bool) {
+	v, ok := cc[name]
+	if !ok {
+		return 0, false
+	}
+
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+
+	return time.Duration(n) * time.Second, true
+}
+
+// heuristicallyCacheable lists the status codes cacheable without explicit freshness.
+//
+//nolint:gochecknoglobals // lookup table
+var heuristicallyCacheable = map[int]struct{}{
+	http.StatusOK:                   {},
+	http.StatusNonAuthoritativeInfo: {},
+	http.StatusNoContent:            {},
+	http.StatusMultipleChoices:      {},
+	http.StatusMovedPermanently:     {},
+	http.StatusPermanentRedirect:    {},
+	http.StatusNotFound:             {},
+	http.StatusMethodNotAllowed:     {},
+	http.StatusGone:                 {},
+	http.StatusRequestURITooLong:    {},
+	http.StatusNotImplemented:       {},
+}
+
+// storable reports whether the response to the request may be stored by a private
+// cache. Responses with neither an explicit freshness nor a validator are not
+// stored: they could never be reused, and storing them reads their whole body,
+// which never ends for streams such as server-sent events.
+func storable(req *http.Request, res *http.Response) bool {
+	if res.StatusCode == http.StatusPartialContent || res.StatusCode < http.StatusOK {
+		return false
+	}
+
+	reqCC, resCC := parseCacheControl(req.Header), parseCacheControl(res.Header)
+	if reqCC.has("no-store") || resCC.has("no-store") || res.Header.Get("Vary") == "*" {
+		return false
+	}
+
+	explicit := resCC.has("max-age") || res.Header.Get("Expires") != ""
+	validator := res.Header.Get("ETag") != "" || res.Header.Get("Last-Modified") != ""
+
+	if !explicit && !validator {
+		return false
+	}
+
+	if explicit || resCC.has("public") || resCC.has("private") {
+		return true
+	}
+
+	_, ok := heuristicallyCacheable[res.StatusCode]
+	return ok
+}
+
+// freshnessLifetime returns how long the response stays fresh after its generation.
+func freshnessLifetime(res *http.Response, responseTime time.Time) time.Duration {
+	if d, ok := parseCacheControl(res.Header).seconds("max-age"); ok {
+		return d
+	}
+
+	date := dateOf(res, responseTime)
+
+	if v := res.Header.Get("Expires"); v != "" {
+		expires, err := http.ParseTime(v)
+		if err != nil {
+			return 0
+		}
+		return max(expires.Sub(date), 0)
+	}
+
+	if _, ok := heuristicallyCacheable[res.StatusCode]; !ok {
+		return 0
+	}
+
+	if lastModified, err := http.ParseTime(res.Header.Get("Last-Modified")); err == nil && date.After(lastModified) {
+		return date.Sub(lastModified) / heuristicFraction
+	}
+
+	return 0
+}
+
+// currentAge returns the age of the response at now, as defined by RFC 9111 section 4.2.3.
+func currentAge(res *http.Response, requestTime, responseTime, now time.Time) time.Duration {
+	apparentAge := max(responseTime.Sub(dateOf(res, responseTime)), 0)
+
+	var ageValue time.Duration
+	if n, err := strconv.ParseInt(res.Header.Get("Age"), 10, 64); err == nil && n > 0 {
+		ageValue = time.Duration(n) * time.Second
+	}
+
+	correctedAge := ageValue + responseTime.Sub(requestTime)
+
+	return max(apparentAge, correctedAge) + now.Sub(responseTime)
+}
+
+// dateOf returns the Date of the response, or fallback when it is missing or invalid.
+func dateOf(res *http.Response, fallback time.Time) time.Time {
+	date, err := http.ParseTime(res.Header.Get("Date"))
+	if err != nil {
+		return fallback
+	}
+
+	return date
+}
+
+// fresh reports whether a response of the given age and freshness lifetime can
+// be served without revalidation for the request.
+func fresh(req *http.Request, res *http.Response, age, lifetime time.Duration) bool {
+	reqCC, resCC := parseCacheControl(req.Header), parseCacheControl(res.Header)
+
+	if reqCC.has("no-cache") || resCC.has("no-cache") {
+		return false
+	}
+
+	if len(reqCC) == 0 && req.Header.Get("Pragma") == "no-cache" {
+		return false
+	}
+
+	if maxAge, ok := reqCC.seconds("max-age"); ok && age > maxAge {
+		return false
+	}
+
+	if minFresh, ok := reqCC.seconds("min-fresh"); ok && lifetime-age < minFresh {
+		return false
+	}
+
+	if lifetime > age {
+		return true
+	}
+
+	if !reqCC.has("max-stale") || resCC.has("must-revalidate") {
+		return false
+	}
+
+	maxStale, ok := reqCC.seconds("max-stale")
+
+	return !ok || age-lifetime <= maxStale
+}
@@ -0,0 +1,38 @@
+package cache
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Option represents a configuration setting that can be applied to a Cache.
+type Option func(c *Cache)
+
+// apply sets the given Option to the Cache.
+func (o Option) apply(c *Cache) {
+	o(c)
+}
+
+// WithMaxEntrySize sets the maximum size of the response bodies stored by the
+// Cache, DefaultMaxEntrySize by default. Larger responses are passed through
+// without being read in memory past the limit. Zero disables the limit.
+func WithMaxEntrySize(n int64) Option {
+	return func(c *Cache) {
+		c.maxEntrySize = n
+	}
+}
+
+// WithLogger sets the logger used to report store failures.
+func WithLogger(logger *zap.Logger) Option {
+	return func(c *Cache) {
+		c.logger = logger
+	}
+}
+
+// WithNow sets the clock of the Cache.
+func WithNow(now func() time.Time) Option {
+	return func(c *Cache) {
+		c.now = now
+	}
+}
@@ -0,0 +1,122 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+)
+
+// Store persists cache entries by key. Implementations must be safe for concurrent use.
+type Store interface {
+	// Get returns the value stored for key, if any.
+	Get(key string) ([]byte, bool, error)
+	// Set stores value for key, replacing any previous value.
+	Set(key string, value []byte) error
+	// Delete removes the value stored for key, if any.
+	Delete(key string) error
+}
+
+// MemoryStore is an in-memory Store evicting the least recently used entries
+// once the total size of the values exceeds its bound.
+type MemoryStore struct {
+	maxBytes int64
+
+	mu    sync.Mutex
+	size  int64
+	order *list.List
+	items map[string]*list.Element
+}
+
+// memoryItem is an entry of a MemoryStore.
+type memoryItem struct {
+	key   string
+	value []byte
+}
+
+// NewMemoryStore creates a MemoryStore holding at most maxBytes of values.
+func NewMemoryStore(maxBytes int64) *MemoryStore {
+	return &MemoryStore{
+		maxBytes: maxBytes,
+		order:    list.New(),
+		items:    map[string]*list.Element{},
+	}
+}
+
+// Get returns the value stored for key and marks it as recently used.
+func (s *MemoryStore) Get(key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.items[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	item, ok := e.Value.(*memoryItem)
+	if !ok {
+		return nil, false, nil
+	}
+
+	s.order.MoveToFront(e)
+
+	return item.value, true, nil
+}
+
+// Set stores value for key and evicts the least recently used entries if needed.
+// Values larger than the bound of the store are not stored.
+func (s *MemoryStore) Set(key string, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.remove(key)
+
+	if int64(len(value)) > s.maxBytes {
+		return nil
+	}
+
+	s.items[key] = s.order.PushFront(&memoryItem{key: key, value: value})
+	s.size += int64(len(value))
+
+	for s.size > s.maxBytes {
+		item, ok := s.order.Back().Value.(*memoryItem)
+		if !ok {
+			break
+		}
+
+		s.remove(item.key)
+	}
+
+	return nil
+}
+
+// Delete removes the value stored for key.
+func (s *MemoryStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.remove(key)
+
+	return nil
+}
+
+// Len returns the number of entries in the store.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.order.Len()
+}
+
+// remove deletes key from the store, the lock must be held.
+func (s *MemoryStore) remove(key string) {
+	e, ok := s.items[key]
+	if !ok {
+		return
+	}
+
+	s.order.Remove(e)
+	delete(s.items, key)
+
+	if item, ok := e.Value.(*memoryItem); ok {
+		s.size -= int64(len(item.value))
+	}
+}
@@ -0,0 +1,187 @@
+package do
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// sharedResponse is a response fanned out to every coalesced request.
+type sharedResponse struct {
+	res  *http.Response
+	body []byte
+}
+
+// coalescedCall is a round trip shared by identical requests.
+type coalescedCall struct {
+	done   chan struct{}
+	res    *sharedResponse
+	err    error
+	cancel context.CancelFunc
+
+	// waiters is the number of requests waiting for the call, guarded by the coalescer.
+	waiters int
+}
+
+// coalescer holds the round trips in flight, by request key.
+type coalescer struct {
+	mu    sync.Mutex
+	calls map[string]*coalescedCall
+}
+
+// WithCoalescing deduplicates identical GET, HEAD and OPTIONS requests in flight:
+// requests with the same method, URL, credentials and values of the given headers
+// share a single round trip. The credentials are the Authorization and Cookie
+// headers, always part of the comparison. Each request receives its own copy of
+// the response, with an independent body, so response handlers such as
+// WithUnmarshalBody run for all of them. The shared round trip carries the values
+// of the context of the first request but is not cancelled with it: it is
+// cancelled once every request sharing it stopped waiting for it.
+//
+// Coalesced responses are read in memory and must not be used for streams. The
+// deduplication is shared by every request using the option, including the
+// requests of rest clients and their children.
+func WithCoalescing(headers ...string) Option {
+	calls := &coalescer{calls: map[string]*coalescedCall{}}
+
+	return WithClientDecorator(func(next HTTPClientDoer) HTTPClientDoer {
+		return &coalescingClient{next: next, calls: calls, headers: headers}
+	})
+}
+
+// coalescingClient is an HTTPClientDoer sharing the round trip of identical requests.
+type coalescingClient struct {
+	next    HTTPClientDoer
+	calls   *coalescer
+	headers []string
+}
+
+// Do sends the request or waits for an identical request in flight.
+func (c *coalescingClient) Do(req *http.Request) (*http.Response, error) {
+	if !isCoalescable(req) {
+		return c.next.Do(req)
+	}
+
+	key := c.key(req)
+	call := c.join(key, req)
+	defer c.leave(key, call)
+
+	select {
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	case <-call.done:
+		if call.err != nil {
+			return nil, call.err
+		}
+
+		res := *call.res.res
+		res.Header = call.res.res.Header.Clone()
+		res.Body = io.NopCloser(bytes.NewReader(call.res.body))
+		res.Request = req
+
+		return &res, nil
+	}
+}
+
+// join returns the call in flight for key, starting it with req if there is none.
+func (c *coalescingClient) join(key string, req *http.Request) *coalescedCall {
+	c.calls.mu.Lock()
+	defer c.calls.mu.Unlock()
+
+	call, ok := c.calls.calls[key]
+	if !ok {
+		ctx, cancel := context.WithCancel(context.WithoutCancel(req.Context()))
+		call = &coalescedCall{done: make(chan struct{}), cancel: cancel}
+		c.calls.calls[key] = call
+
+		go c.run(key, call, req.WithContext(ctx))
+	}
+
+	call.waiters++
+
+	return call
+}
+
+// leave stops waiting for the call, which is cancelled once nobody waits for it anymore.
+func (c *coalescingClient) leave(key string, call *coalescedCall) {
+	c.calls.mu.Lock()
+	defer c.calls.mu.Unlock()
+
+	call.waiters--
+	if call.waiters > 0 {
+		return
+	}
+
+	call.cancel()
+
+	if c.calls.calls[key] == call {
+		delete(c.calls.calls, key)
+	}
+}
+
+// run sends the request of the call and reads its response.
+func (c *coalescingClient) run(key string, call *coalescedCall, req *http.Request) {
+	defer call.cancel()
+
+	call.res, call.err = c.roundTrip(req)
+
+	c.calls.mu.Lock()
+	if c.calls.calls[key] == call {
+		delete(c.calls.calls, key)
+	}
+	c.calls.mu.Unlock()
+
+	close(call.done)
+}
+
+// roundTrip sends the request and reads the response in memory.
+func (c *coalescingClient) roundTrip(req *http.Request) (*sharedResponse, error) {
+	res, err := c.next.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.Body == nil {
+		return &sharedResponse{res: res}, nil
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read coalesced response: %w", err)
+	}
+
+	return &sharedResponse{res: res, body: body}, nil
+}
+
+// key identifies the requests sharing a round trip.
+func (c *coalescingClient) key(req *http.Request) string {
+	var b strings.Builder
+
+	b.WriteString(req.Method)
+	b.WriteByte(' ')
+	b.WriteString(req.URL.String())
+
+	for _, name := range append([]string{"Authorization", "Cookie"}, c.headers...) {
+		b.WriteByte('\n')
+		b.WriteString(name)
+		b.WriteByte(':')
+		b.WriteString(strings.Join(req.Header.Values(name), ","))
+	}
+
+	return b.String()
+}
+
+// isCoalescable reports whether the request can share its round trip.
+func isCoalescable(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return req.Body == nil || req.Body == http.NoBody
+	default:
+		return false
+	}
+}
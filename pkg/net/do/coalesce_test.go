@@ -0,0 +1,193 @@
+package do_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/merlindorin/go-shared/pkg/must"
+	"github.com/merlindorin/go-shared/pkg/net/do"
+)
+
+func TestWithCoalescing(t *testing.T) {
+	u := must.Get(url.Parse("http://localhost/items"))
+
+	t.Run("should share the response of identical requests", func(t *testing.T) {
+		release := make(chan struct{})
+
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).RunAndReturn(func(*http.Request) (*http.Response, error) {
+			<-release
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": {"application/json"}},
+				Body:       io.NopCloser(strings.NewReader(`{"name":"item"}`)),
+			}, nil
+		}).Once()
+
+		coalescing := do.WithCoalescing()
+
+		var wg sync.WaitGroup
+		results := make([]item, 5)
+
+		for i := range results {
+			wg.Go(func() {
+				assert.NoError(t, do.Do(t.Context(), u,
+					do.WithClient(mockClient),
+					coalescing,
+					do.WithUnmarshalBody(&results[i]),
+				))
+			})
+		}
+
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		for _, result := range results {
+			assert.Equal(t, item{Name: "item"}, result)
+		}
+	})
+
+	t.Run("should not share requests with different selected headers", func(t *testing.T) {
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).RunAndReturn(func(*http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(""))}, nil
+		}).Twice()
+
+		coalescing := do.WithCoalescing("X-Tenant")
+
+		for _, tenant := range []string{"a", "b"} {
+			err := do.Do(t.Context(), u, do.WithClient(mockClient), coalescing, do.WithExtraHeader("X-Tenant", tenant))
+			assert.NoError(t, err)
+		}
+	})
+
+	t.Run("should not share requests with different credentials", func(t *testing.T) {
+		release := make(chan struct{})
+
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).RunAndReturn(func(*http.Request) (*http.Response, error) {
+			<-release
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(""))}, nil
+		}).Times(3)
+
+		coalescing := do.WithCoalescing()
+		credentials := []do.Option{
+			do.WithExtraHeader("Authorization", "Bearer a"),
+			do.WithExtraHeader("Authorization", "Bearer b"),
+			do.WithExtraHeader("Cookie", "session=a"),
+		}
+
+		var wg sync.WaitGroup
+		for _, credential := range credentials {
+			wg.Go(func() {
+				assert.NoError(t, do.Do(t.Context(), u, do.WithClient(mockClient), coalescing, credential))
+			})
+		}
+
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+		wg.Wait()
+	})
+
+	t.Run("should not cancel the shared round trip with the first request", func(t *testing.T) {
+		release := make(chan struct{})
+
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).RunAndReturn(func(req *http.Request) (*http.Response, error) {
+			<-release
+			if err := req.Context().Err(); err != nil {
+				return nil, err
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(""))}, nil
+		}).Once()
+
+		coalescing := do.WithCoalescing()
+		ctx, cancel := context.WithCancel(t.Context())
+
+		var wg sync.WaitGroup
+		wg.Go(func() {
+			assert.ErrorIs(t, do.Do(ctx, u, do.WithClient(mockClient), coalescing), context.Canceled)
+		})
+
+		time.Sleep(10 * time.Millisecond)
+		wg.Go(func() {
+			assert.NoError(t, do.Do(t.Context(), u, do.WithClient(mockClient), coalescing))
+		})
+
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+		close(release)
+		wg.Wait()
+	})
+
+	t.Run("should cancel the shared round trip once every request left", func(t *testing.T) {
+		cancelled := make(chan struct{})
+
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).RunAndReturn(func(req *http.Request) (*http.Response, error) {
+			<-req.Context().Done()
+			close(cancelled)
+			return nil, req.Context().Err()
+		}).Once()
+
+		coalescing := do.WithCoalescing()
+		ctx, cancel := context.WithCancel(t.Context())
+
+		var wg sync.WaitGroup
+		for range 2 {
+			wg.Go(func() {
+				assert.ErrorIs(t, do.Do(ctx, u, do.WithClient(mockClient), coalescing), context.Canceled)
+			})
+		}
+
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+		wg.Wait()
+
+		select {
+		case <-cancelled:
+		case <-time.After(time.Second):
+			t.Fatal("the shared round trip was not cancelled")
+		}
+	})
+
+	t.Run("should share responses without body", func(t *testing.T) {
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).Return(&http.Response{StatusCode: http.StatusNoContent}, nil).Once()
+
+		assert.NoError(t, do.Do(t.Context(), u, do.WithClient(mockClient), do.WithCoalescing()))
+	})
+
+	t.Run("should not share unsafe requests", func(t *testing.T) {
+		release := make(chan struct{})
+
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).RunAndReturn(func(*http.Request) (*http.Response, error) {
+			<-release
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(""))}, nil
+		}).Twice()
+
+		coalescing := do.WithCoalescing()
+
+		var wg sync.WaitGroup
+		for range 2 {
+			wg.Go(func() {
+				assert.NoError(t, do.Do(t.Context(), u, do.WithClient(mockClient), coalescing, do.WithMethod(http.MethodPost)))
+			})
+		}
+
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+		wg.Wait()
+	})
+}
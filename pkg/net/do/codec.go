@@ -0,0 +1,282 @@
+package do
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"mime"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// Media types of the built-in codecs.
+const (
+	JSONContentType        = "application/json"
+	XMLContentType         = "application/xml"
+	FormContentType        = "application/x-www-form-urlencoded"
+	NDJSONContentType      = "application/x-ndjson"
+	TextContentType        = "text/plain"
+	OctetStreamContentType = "application/octet-stream"
+)
+
+// ErrUnsupportedValue is returned by a Codec that cannot encode or decode a value
+// of the given type. WithUnmarshalBody then falls back to the default Codec.
+var ErrUnsupportedValue = errors.New("unsupported value")
+
+// Codec encodes request bodies and decodes response bodies for a media type.
+type Codec interface {
+	// ContentType returns the media type handled by the codec.
+	ContentType() string
+	// Marshal encodes v.
+	Marshal(v any) ([]byte, error)
+	// Unmarshal decodes data into v.
+	Unmarshal(data []byte, v any) error
+}
+
+// Codecs is a registry of codecs keyed by media type.
+type Codecs struct {
+	codecs map[string]Codec
+}
+
+// NewCodecs creates a registry with the given codecs.
+func NewCodecs(codecs ...Codec) *Codecs {
+	c := &Codecs{codecs: map[string]Codec{}}
+
+	for _, codec := range codecs {
+		c.Register(codec)
+	}
+
+	return c
+}
+
+// DefaultCodecs creates a registry with the built-in JSON, XML, form, NDJSON,
+// text and octet-stream codecs.
+func DefaultCodecs() *Codecs {
+	return NewCodecs(
+		JSONCodec{},
+		XMLCodec{},
+		FormCodec{},
+		NDJSONCodec{},
+		RawCodec{Type: TextContentType},
+		RawCodec{Type: OctetStreamContentType},
+	)
+}
+
+// Register adds the codec under its media type, replacing any codec registered for it.
+func (c *Codecs) Register(codec Codec) {
+	c.codecs[strings.ToLower(codec.ContentType())] = codec
+}
+
+// Lookup returns the codec for a Content-Type header value. Structured syntax
+// suffixes such as application/problem+json fall back to the codec of their suffix.
+func (c *Codecs) Lookup(contentType string) (Codec, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, false
+	}
+
+	if codec, ok := c.codecs[mediaType]; ok {
+		return codec, true
+	}
+
+	if i := strings.LastIndex(mediaType, "+"); i >= 0 {
+		typ, _, _ := strings.Cut(mediaType, "/")
+		codec, ok := c.codecs[typ+"/"+mediaType[i+1:]]
+		return codec, ok
+	}
+
+	return nil, false
+}
+
+// JSONCodec is the application/json Codec.
+type JSONCodec struct{}
+
+// ContentType returns "application/json".
+func (JSONCodec) ContentType() string {
+	return JSONContentType
+}
+
+// Marshal encodes v to JSON.
+func (JSONCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal decodes JSON data into v.
+func (JSONCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// XMLCodec is the application/xml Codec.
+type XMLCodec struct{}
+
+// ContentType returns "application/xml".
+func (XMLCodec) ContentType() string {
+	return XMLContentType
+}
+
+// Marshal encodes v to XML.
+func (XMLCodec) Marshal(v any) ([]byte, error) {
+	return xml.Marshal(v)
+}
+
+// Unmarshal decodes XML data into v.
+func (XMLCodec) Unmarshal(data []byte, v any) error {
+	return xml.Unmarshal(data, v)
+}
+
+// FormCodec is the application/x-www-form-urlencoded Codec. It encodes url.Values,
+// map[string][]string and map[string]string, and decodes into pointers to them.
+type FormCodec struct{}
+
+// ContentType returns "application/x-www-form-urlencoded".
+func (FormCodec) ContentType() string {
+	return FormContentType
+}
+
+// Marshal encodes v as a form.
+func (FormCodec) Marshal(v any) ([]byte, error) {
+	var values url.Values
+
+	switch t := v.(type) {
+	case url.Values:
+		values = t
+	case map[string][]string:
+		values = t
+	case map[string]string:
+		values = url.Values{}
+		for k, s := range t {
+			values.Set(k, s)
+		}
+	default:
+		return nil, fmt.Errorf("cannot encode %T as a form: %w", v, ErrUnsupportedValue)
+	}
+
+	return []byte(values.Encode()), nil
+}
+
+// Unmarshal decodes form data into v.
+func (FormCodec) Unmarshal(data []byte, v any) error {
+	values, err := url.ParseQuery(string(data))
+	if err != nil {
+		return err
+	}
+
+	switch t := v.(type) {
+	case *url.Values:
+		*t = values
+	case *map[string][]string:
+		*t = values
+	case *map[string]string:
+		*t = make(map[string]string, len(values))
+		for k := range values {
+			(*t)[k] = values.Get(k)
+		}
+	default:
+		return fmt.Errorf("cannot decode a form into %T: %w", v, ErrUnsupportedValue)
+	}
+
+	return nil
+}
+
+// NDJSONCodec is the application/x-ndjson Codec. It encodes each element of a
+// slice as a JSON line and decodes each line into an element of a slice pointer.
+// Use WithNDJSONStream to decode a stream incrementally.
+type NDJSONCodec struct{}
+
+// ContentType returns "application/x-ndjson".
+func (NDJSONCodec) ContentType() string {
+	return NDJSONContentType
+}
+
+// Marshal encodes the elements of the slice v as JSON lines.
+func (NDJSONCodec) Marshal(v any) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, fmt.Errorf("cannot encode %T as ndjson, a slice is expected: %w", v, ErrUnsupportedValue)
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+
+	for i := range rv.Len() {
+		if err := enc.Encode(rv.Index(i).Interface()); err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Unmarshal appends each JSON line of data to the slice pointed to by v.
+func (NDJSONCodec) Unmarshal(data []byte, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("cannot decode ndjson into %T, a slice pointer is expected: %w", v, ErrUnsupportedValue)
+	}
+
+	slice := rv.Elem()
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		elem := reflect.New(slice.Type().Elem())
+		if err := json.Unmarshal(line, elem.Interface()); err != nil {
+			return err
+		}
+
+		slice.Set(reflect.Append(slice, elem.Elem()))
+	}
+
+	return scanner.Err()
+}
+
+// RawCodec passes bodies through untouched. It encodes []byte and string values
+// and decodes into *[]byte and *string.
+type RawCodec struct {
+	// Type is the media type of the codec, application/octet-stream when empty.
+	Type string
+}
+
+// ContentType returns the media type of the codec.
+func (c RawCodec) ContentType() string {
+	if c.Type == "" {
+		return OctetStreamContentType
+	}
+
+	return c.Type
+}
+
+// Marshal returns v as bytes.
+func (c RawCodec) Marshal(v any) ([]byte, error) {
+	switch t := v.(type) {
+	case []byte:
+		return t, nil
+	case string:
+		return []byte(t), nil
+	default:
+		return nil, fmt.Errorf("cannot encode %T as %s: %w", v, c.ContentType(), ErrUnsupportedValue)
+	}
+}
+
+// Unmarshal copies data into v.
+func (c RawCodec) Unmarshal(data []byte, v any) error {
+	switch t := v.(type) {
+	case *[]byte:
+		*t = bytes.Clone(data)
+	case *string:
+		*t = string(data)
+	default:
+		return fmt.Errorf("cannot decode %s into %T: %w", c.ContentType(), v, ErrUnsupportedValue)
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package do_test
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/merlindorin/go-shared/pkg/must"
+	"github.com/merlindorin/go-shared/pkg/net/do"
+)
+
+type item struct {
+	XMLName xml.Name `json:"-"    xml:"item"`
+	Name    string   `json:"name" xml:"name"`
+}
+
+func TestCodecs(t *testing.T) {
+	t.Run("should look up codecs by media type and suffix", func(t *testing.T) {
+		codecs := do.DefaultCodecs()
+
+		codec, ok := codecs.Lookup("application/json; charset=utf-8")
+		assert.True(t, ok)
+		assert.Equal(t, do.JSONContentType, codec.ContentType())
+
+		codec, ok = codecs.Lookup("application/atom+xml")
+		assert.True(t, ok)
+		assert.Equal(t, do.XMLContentType, codec.ContentType())
+
+		_, ok = codecs.Lookup("image/png")
+		assert.False(t, ok)
+	})
+
+	t.Run("should round trip forms", func(t *testing.T) {
+		b, err := do.FormCodec{}.Marshal(map[string]string{"a": "1", "b": "x y"})
+		assert.NoError(t, err)
+		assert.Equal(t, "a=1&b=x+y", string(b))
+
+		var got map[string]string
+		assert.NoError(t, do.FormCodec{}.Unmarshal(b, &got))
+		assert.Equal(t, map[string]string{"a": "1", "b": "x y"}, got)
+	})
+
+	t.Run("should round trip ndjson", func(t *testing.T) {
+		b, err := do.NDJSONCodec{}.Marshal([]item{{Name: "a"}, {Name: "b"}})
+		assert.NoError(t, err)
+		assert.Equal(t, "{\"name\":\"a\"}\n{\"name\":\"b\"}\n", string(b))
+
+		var got []item
+		assert.NoError(t, do.NDJSONCodec{}.Unmarshal(b, &got))
+		assert.Equal(t, []item{{Name: "a"}, {Name: "b"}}, got)
+	})
+
+	t.Run("should pass raw bodies through", func(t *testing.T) {
+		var got string
+		assert.NoError(t, do.RawCodec{}.Unmarshal([]byte("hello"), &got))
+		assert.Equal(t, "hello", got)
+		assert.Equal(t, do.OctetStreamContentType, do.RawCodec{}.ContentType())
+	})
+
+	t.Run("should set the content type and accept headers", func(t *testing.T) {
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).RunAndReturn(func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, do.XMLContentType, req.Header.Get("Content-Type"))
+			assert.Equal(t, do.XMLContentType, req.Header.Get("Accept"))
+			assert.Equal(t, "<item><name>in</name></item>", string(must.Get(io.ReadAll(req.Body))))
+
+			return &http.Response{
+				Header: http.Header{"Content-Type": []string{"application/xml"}},
+				Body:   io.NopCloser(strings.NewReader("<item><name>out</name></item>")),
+			}, nil
+		}).Once()
+
+		var out item
+		err := do.Do(
+			context.TODO(),
+			&url.URL{},
+			do.WithClient(mockClient),
+			do.WithCodec(do.XMLCodec{}),
+			do.WithMarshalBody(item{Name: "in"}),
+			do.WithUnmarshalBody(&out),
+		)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "out", out.Name)
+	})
+
+	t.Run("should select the decoding codec from the response content type", func(t *testing.T) {
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).Return(&http.Response{
+			Header: http.Header{"Content-Type": []string{"application/xml"}},
+			Body:   io.NopCloser(strings.NewReader("<item><name>xml</name></item>")),
+		}, nil).Once()
+
+		var out item
+		err := do.Do(context.TODO(), &url.URL{}, do.WithClient(mockClient), do.WithUnmarshalBody(&out))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "xml", out.Name)
+	})
+
+	t.Run("should fall back to the default codec for JSON served as text/plain", func(t *testing.T) {
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).RunAndReturn(func(*http.Request) (*http.Response, error) {
+			return &http.Response{
+				Header: http.Header{"Content-Type": []string{"text/plain; charset=utf-8"}},
+				Body:   io.NopCloser(strings.NewReader("[1, 2, 3]")),
+			}, nil
+		}).Twice()
+
+		var out []int
+		err := do.Do(context.TODO(), &url.URL{}, do.WithClient(mockClient), do.WithUnmarshalBody(&out))
+
+		assert.NoError(t, err)
+		assert.Equal(t, []int{1, 2, 3}, out)
+
+		var text string
+		err = do.Do(context.TODO(), &url.URL{}, do.WithClient(mockClient), do.WithUnmarshalBody(&text))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "[1, 2, 3]", text)
+	})
+}
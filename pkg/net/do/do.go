@@ -1,6 +1,7 @@
 package do
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -10,15 +11,25 @@ import (
 	"time"
 
 	"go.uber.org/zap"
+
+	"github.com/merlindorin/go-shared/pkg/logger"
 )
 
 // Do executes an HTTP request to the given URL with the provided options.
+//
+// The post-request handlers run in order until one of them fails: the handlers
+// after it are skipped and its error is given to the error handlers, then
+// returned. A handler failing with ErrResendRequest sends the request once more
+// instead, and the post-request handlers run on the new response.
 func Do(ctx context.Context, u *url.URL, options ...Option) error {
 	defaultOptions := []Option{
 		WithMethod(http.MethodGet),
 		WithClient(http.DefaultClient),
 		WithLogger(zap.NewNop()),
 		WithNow(time.Now),
+		WithErrorDecoder(DecodeProblemDetails),
+		WithCodec(JSONCodec{}),
+		WithCodecs(DefaultCodecs()),
 	}
 
 	p := NewParams()
@@ -27,56 +38,63 @@ func Do(ctx context.Context, u *url.URL, options ...Option) error {
 		option(p)
 	}
 
+	p.decorateClient()
+
 	start := p.now()
-	log := p.Logger.With(zap.Time("start", start))
+	log := logger.WithContext(ctx, p.Logger).With(zap.Time("start", start))
 
-	log.Debug("buildRequest", zap.Duration("duration", time.Since(start)))
-	req, err := http.NewRequestWithContext(ctx, p.Method, u.JoinPath(p.Path).String(), p.Body)
+	body, err := newBodyFunc(p)
 	if err != nil {
-		log.Error("cannot buildRequest", zap.Error(err))
+		log.Error("cannot read request body", zap.Error(err))
 		return err
 	}
 
-	// Run pre-request handlers.
-	for name, preRequestHandler := range p.PreRequestHandlers {
-		log.Debug("preRequest", zap.Duration("duration", time.Since(start)), zap.String("preRequestHandlerName", name))
-		if err = preRequestHandler.Apply(ctx, req); err != nil {
-			log.Error("cannot handle request", zap.Error(err), zap.String("preRequestHandlerName", name))
-			return err
-		}
+	p.newRequest = func(ctx context.Context) (*http.Request, error) {
+		return buildRequest(ctx, u, p, body, log, start)
 	}
 
-	log.Debug("sendRequest", zap.Duration("duration", time.Since(start)))
-	res, err := p.Client.Do(req) //nolint:bodyclose // it is managed below
-	if err != nil {
-		log.Error("cannot sendRequest", zap.Error(err))
-		return err
-	}
+	var (
+		req     *http.Request
+		res     *http.Response
+		resBody io.ReadCloser
+	)
 
-	defer func(body io.ReadCloser) {
-		if body != nil {
-			if er := body.Close(); er != nil {
-				log.Error("cannot close response body", zap.Error(er))
-				err = errors.Join(err)
-			}
+	for resent := false; ; resent = true {
+		req, res, log, err = send(ctx, u, p, body, start)
+		if err != nil {
+			return err
 		}
-	}(res.Body)
 
-	// Run post-request handlers.
-	for name, postRequestHandler := range p.PostRequestHandlers {
-		log.Debug("postRequest", zap.Duration("duration", time.Since(start)), zap.String("postRequestHandlerName", name))
+		// Handlers may replace the body, the one of the client is kept to be closed.
+		resBody = res.Body
 
-		if err = postRequestHandler.Apply(ctx, req, res); err != nil {
-			log.Info("cannot handle response", zap.Error(err), zap.String("postRequestHandlerName", name))
-			continue
+		err = handleResponse(ctx, p, req, res, log, start, resent)
+		if resent || !errors.Is(err, ErrResendRequest) {
+			break
 		}
+
+		log.Info("resendRequest")
+		res.Body = resBody
+		discardBody(res, log)
 	}
 
+	defer func() {
+		if resBody != nil {
+			if er := resBody.Close(); er != nil {
+				log.Error("cannot close response body", zap.Error(er))
+			}
+		}
+	}()
+
 	// Run error handlers.
-	for name, errorHandler := range p.ErrorHandlers {
+	for name, errorHandler := range p.ErrorHandlers.All() {
 		log.Debug("errorHandler", zap.Duration("duration", time.Since(start)), zap.String("errorHandlerName", name))
 
-		if err = errorHandler.Apply(ctx, req, res, err); err != nil {
+		handlerStart := p.now()
+		err = errorHandler.Apply(ctx, req, res, err)
+		p.observeHandler(ctx, ErrorHandlerKind, name, handlerStart, err)
+
+		if err != nil {
 			log.Error("cannot handle response", zap.Error(err), zap.String("postRequestHandlerName", name))
 			return err
 		}
@@ -88,3 +106,235 @@ func Do(ctx context.Context, u *url.URL, options ...Option) error {
 
 	return nil
 }
+
+// send builds and sends the request, retrying the attempts according to the
+// retry policy, and returns the last request and response along with its logger.
+func send(
+	ctx context.Context,
+	u *url.URL,
+	p *Params,
+	body func() (io.Reader, error),
+	start time.Time,
+) (*http.Request, *http.Response, *zap.Logger, error) {
+	var (
+		req   *http.Request
+		res   *http.Response
+		log   *zap.Logger
+		delay time.Duration
+		err   error
+	)
+
+	for attempt := 1; ; attempt++ {
+		log = logger.WithContext(ctx, p.Logger).With(zap.Time("start", start), zap.Int("attempt", attempt))
+
+		req, err = buildRequest(ctx, u, p, body, log, start)
+		if err != nil {
+			return nil, nil, log, err
+		}
+
+		log.Debug("sendRequest", zap.Duration("duration", time.Since(start)))
+		res, err = p.Client.Do(req) //nolint:bodyclose // it is managed by Do
+		if err != nil {
+			log.Error("cannot sendRequest", zap.Error(err))
+		}
+
+		if !shouldRetry(ctx, p, attempt, res, err) {
+			break
+		}
+
+		delay = p.Retry.Delay(attempt, delay, res, p.now())
+		if deadline, ok := ctx.Deadline(); ok && p.now().Add(delay).After(deadline) {
+			log.Debug("retry would exceed the context deadline", zap.Duration("delay", delay))
+			break
+		}
+
+		discardBody(res, log)
+
+		log.Info("retryRequest", zap.Duration("delay", delay), zap.Error(err))
+		if err = sleep(ctx, delay); err != nil {
+			log.Error("cannot retry request", zap.Error(err))
+			return nil, nil, log, err
+		}
+	}
+
+	if err != nil {
+		return nil, nil, log, err
+	}
+
+	return req, res, log, nil
+}
+
+// handleResponse runs the post-request handlers until the first failure. Once
+// the request has been resent, ErrResendRequest is ignored.
+func handleResponse(
+	ctx context.Context,
+	p *Params,
+	req *http.Request,
+	res *http.Response,
+	log *zap.Logger,
+	start time.Time,
+	resent bool,
+) error {
+	for name, postRequestHandler := range p.PostRequestHandlers.All() {
+		log.Debug("postRequest", zap.Duration("duration", time.Since(start)), zap.String("postRequestHandlerName", name))
+
+		handlerStart := p.now()
+		err := postRequestHandler.Apply(ctx, req, res)
+		p.observeHandler(ctx, PostRequestHandlerKind, name, handlerStart, err)
+
+		if resent && errors.Is(err, ErrResendRequest) {
+			continue
+		}
+
+		if err != nil {
+			log.Info("cannot handle response", zap.Error(err), zap.String("postRequestHandlerName", name))
+			return err
+		}
+	}
+
+	return nil
+}
+
+// buildRequest creates the request for a single attempt and runs the pre-request handlers on it.
+func buildRequest(
+	ctx context.Context,
+	u *url.URL,
+	p *Params,
+	body func() (io.Reader, error),
+	log *zap.Logger,
+	start time.Time,
+) (*http.Request, error) {
+	log.Debug("buildRequest", zap.Duration("duration", time.Since(start)))
+	b, err := body()
+	if err != nil {
+		log.Error("cannot buildRequest", zap.Error(err))
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, p.Method, u.JoinPath(p.Path).String(), b)
+	if err != nil {
+		log.Error("cannot buildRequest", zap.Error(err))
+		return nil, err
+	}
+
+	// Run pre-request handlers.
+	for name, preRequestHandler := range p.PreRequestHandlers.All() {
+		log.Debug("preRequest", zap.Duration("duration", time.Since(start)), zap.String("preRequestHandlerName", name))
+
+		handlerStart := p.now()
+		err = preRequestHandler.Apply(ctx, req)
+		p.observeHandler(ctx, PreRequestHandlerKind, name, handlerStart, err)
+
+		if err != nil {
+			log.Error("cannot handle request", zap.Error(err), zap.String("preRequestHandlerName", name))
+			discardRequestBody(req, log)
+			return nil, err
+		}
+	}
+
+	return req, nil
+}
+
+// CloneRequest returns a copy of req with ctx and a fresh body, so that it can be
+// sent again. It fails when the body cannot be replayed because GetBody is not set.
+func CloneRequest(ctx context.Context, req *http.Request) (*http.Request, error) {
+	next := req.Clone(ctx)
+
+	switch {
+	case req.GetBody != nil:
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		next.Body = body
+	case req.Body != nil && req.Body != http.NoBody:
+		return nil, ErrBodyNotReplayable
+	}
+
+	return next, nil
+}
+
+// newBodyFunc returns a function providing the request body of each attempt. When
+// retries are enabled, the body is buffered once so that it can be replayed.
+// Otherwise, the body is sent again only when it implements io.Seeker, in which
+// case it is rewound, and providing it again fails with ErrBodyNotReplayable.
+func newBodyFunc(p *Params) (func() (io.Reader, error), error) {
+	if p.Body == nil {
+		return func() (io.Reader, error) { return nil, nil }, nil
+	}
+
+	if p.Retry == nil {
+		b, provided := p.Body, false
+		return func() (io.Reader, error) {
+			if !provided {
+				provided = true
+				return b, nil
+			}
+
+			seeker, ok := b.(io.Seeker)
+			if !ok {
+				return nil, ErrBodyNotReplayable
+			}
+
+			if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+				return nil, err
+			}
+
+			return b, nil
+		}, nil
+	}
+
+	b, err := io.ReadAll(p.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return func() (io.Reader, error) { return bytes.NewReader(b), nil }, nil
+}
+
+// shouldRetry reports whether the attempt should be retried according to the retry policy.
+func shouldRetry(ctx context.Context, p *Params, attempt int, res *http.Response, err error) bool {
+	return p.Retry != nil &&
+		attempt < p.Retry.MaxAttempts() &&
+		ctx.Err() == nil &&
+		p.Retry.ShouldRetry(res, err)
+}
+
+// discardBody drains and closes the body of a response that will not be processed.
+func discardBody(res *http.Response, log *zap.Logger) {
+	if res == nil || res.Body == nil {
+		return
+	}
+
+	if _, err := io.Copy(io.Discard, res.Body); err != nil {
+		log.Debug("cannot drain response body", zap.Error(err))
+	}
+
+	if err := res.Body.Close(); err != nil {
+		log.Error("cannot close response body", zap.Error(err))
+	}
+}
+
+// discardRequestBody closes the body of a request that will not be sent.
+func discardRequestBody(req *http.Request, log *zap.Logger) {
+	if req.Body == nil {
+		return
+	}
+
+	if err := req.Body.Close(); err != nil {
+		log.Debug("cannot close request body", zap.Error(err))
+	}
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
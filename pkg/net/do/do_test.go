@@ -2,20 +2,42 @@ package do_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/merlindorin/go-shared/pkg/logger"
 	"github.com/merlindorin/go-shared/pkg/must"
 	"github.com/merlindorin/go-shared/pkg/net/do"
+	"github.com/merlindorin/go-shared/pkg/net/do/retry"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 )
 
+// closeRecorder is a response body recording whether it was closed.
+type closeRecorder struct {
+	io.Reader
+
+	closed bool
+}
+
+// Close records the call.
+func (b *closeRecorder) Close() error {
+	b.closed = true
+	return nil
+}
+
 func TestDo(t *testing.T) {
 	t.Run("should make request with default options", func(t *testing.T) {
 		wantMethod := http.MethodGet
@@ -158,4 +180,230 @@ func TestDo(t *testing.T) {
 
 		assert.NoError(t, err)
 	})
+
+	t.Run("should retry with the same body until the response is successful", func(t *testing.T) {
+		var bodies []string
+		responses := []*http.Response{{StatusCode: http.StatusServiceUnavailable}, nil, {StatusCode: http.StatusOK}}
+
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).RunAndReturn(func(req *http.Request) (*http.Response, error) {
+			bodies = append(bodies, string(must.Get(io.ReadAll(req.Body))))
+			res := responses[len(bodies)-1]
+			if res == nil {
+				return nil, fmt.Errorf("connection reset")
+			}
+			return res, nil
+		}).Times(3)
+
+		err := do.Do(
+			context.TODO(),
+			&url.URL{},
+			do.WithClient(mockClient),
+			do.WithBody(strings.NewReader("payload")),
+			do.WithRetry(retry.NewPolicy(retry.WithBackoff(retry.Constant(0)))),
+		)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"payload", "payload", "payload"}, bodies)
+	})
+
+	t.Run("should stop retrying after the maximum attempts", func(t *testing.T) {
+		wantErr := fmt.Errorf("connection refused")
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).Return(nil, wantErr).Times(2)
+
+		err := do.Do(
+			context.TODO(),
+			&url.URL{},
+			do.WithClient(mockClient),
+			do.WithRetry(retry.NewPolicy(retry.WithMaxAttempts(2), retry.WithBackoff(retry.Constant(0)))),
+		)
+
+		assert.ErrorIs(t, err, wantErr)
+	})
+
+	t.Run("should not retry beyond the context deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.TODO(), time.Second)
+		defer cancel()
+
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).Return(&http.Response{
+			StatusCode: http.StatusTooManyRequests,
+			Header:     http.Header{"Retry-After": []string{"60"}},
+		}, nil).Once()
+
+		err := do.Do(
+			ctx,
+			&url.URL{},
+			do.WithClient(mockClient),
+			do.WithRetry(retry.NewPolicy()),
+		)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("should close the body of the client when a handler replaced it", func(t *testing.T) {
+		body := &closeRecorder{Reader: strings.NewReader(`{"name":"item"}`)}
+
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).Return(&http.Response{StatusCode: http.StatusOK, Body: body}, nil).Once()
+
+		var got struct {
+			Name string `json:"name"`
+		}
+		err := do.Do(context.TODO(), &url.URL{}, do.WithClient(mockClient), do.WithUnmarshalBody(&got))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "item", got.Name)
+		assert.True(t, body.closed)
+	})
+
+	t.Run("should return an HTTPError and skip the body decoding on error status", func(t *testing.T) {
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).Return(&http.Response{
+			StatusCode: http.StatusNotFound,
+			Body:       io.NopCloser(strings.NewReader(`{"message":"no such user"}`)),
+		}, nil).Once()
+
+		var out map[string]string
+		err := do.Do(
+			context.TODO(),
+			must.Get(url.Parse("http://localhost")),
+			do.WithClient(mockClient),
+			do.WithStatusCheck(),
+			do.WithUnmarshalBody(&out),
+		)
+
+		var httpErr *do.HTTPError
+		assert.ErrorAs(t, err, &httpErr)
+		assert.ErrorIs(t, err, do.ErrNotFound)
+		assert.ErrorIs(t, err, do.ErrClientError)
+		assert.NotErrorIs(t, err, do.ErrServerError)
+		assert.Equal(t, http.StatusNotFound, httpErr.StatusCode)
+		assert.Equal(t, http.MethodGet, httpErr.Method)
+		assert.Equal(t, "http://localhost", httpErr.URL)
+		assert.JSONEq(t, `{"message":"no such user"}`, string(httpErr.Body))
+		assert.Nil(t, out)
+	})
+
+	t.Run("should let error handlers recover from an error status", func(t *testing.T) {
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).Return(&http.Response{
+			StatusCode: http.StatusConflict,
+			Body:       io.NopCloser(strings.NewReader("already exists")),
+		}, nil).Once()
+
+		err := do.Do(
+			context.TODO(),
+			&url.URL{},
+			do.WithClient(mockClient),
+			do.WithStatusCheck(),
+			do.WithErrorHandler("conflict", func(_ context.Context, _ *http.Request, res *http.Response, err error) error {
+				assert.Equal(t, "already exists", string(must.Get(io.ReadAll(res.Body))))
+				if errors.Is(err, do.ErrConflict) {
+					return nil
+				}
+				return err
+			}),
+		)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("should stop the post-request handlers at the first failure", func(t *testing.T) {
+		wantErr := errors.New("failure")
+		var ran []string
+
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).Return(&http.Response{}, nil).Once()
+
+		err := do.Do(
+			context.TODO(),
+			&url.URL{},
+			do.WithClient(mockClient),
+			do.WithPostRequestHandler("failing", func(context.Context, *http.Request, *http.Response) error {
+				ran = append(ran, "failing")
+				return wantErr
+			}),
+			do.WithPostRequestHandler("skipped", func(context.Context, *http.Request, *http.Response) error {
+				ran = append(ran, "skipped")
+				return nil
+			}),
+			do.WithErrorHandler("seen", func(_ context.Context, _ *http.Request, _ *http.Response, err error) error {
+				ran = append(ran, "seen")
+				return err
+			}),
+		)
+
+		assert.ErrorIs(t, err, wantErr)
+		assert.Equal(t, []string{"failing", "seen"}, ran)
+	})
+
+	t.Run("should resend the request once through the pre-request handlers", func(t *testing.T) {
+		var prepared, handled int
+
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).RunAndReturn(func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, "payload", string(must.Get(io.ReadAll(req.Body))))
+			assert.Equal(t, strconv.Itoa(prepared), req.Header.Get("X-Attempt"))
+			return &http.Response{Body: http.NoBody}, nil
+		}).Twice()
+
+		err := do.Do(
+			context.TODO(),
+			&url.URL{},
+			do.WithClient(mockClient),
+			do.WithBody(strings.NewReader("payload")),
+			do.WithPreRequestHandler("attempt", func(_ context.Context, req *http.Request) error {
+				prepared++
+				req.Header.Set("X-Attempt", strconv.Itoa(prepared))
+				return nil
+			}),
+			do.WithPostRequestHandler("resend", func(context.Context, *http.Request, *http.Response) error {
+				handled++
+				return do.ErrResendRequest
+			}),
+		)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, prepared)
+		assert.Equal(t, 2, handled)
+	})
+
+	t.Run("should not resend a request whose body cannot be replayed", func(t *testing.T) {
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).Return(&http.Response{Body: http.NoBody}, nil).Once()
+
+		err := do.Do(
+			context.TODO(),
+			&url.URL{},
+			do.WithClient(mockClient),
+			do.WithBody(io.MultiReader(strings.NewReader("payload"))),
+			do.WithPostRequestHandler("resend", func(context.Context, *http.Request, *http.Response) error {
+				return do.ErrResendRequest
+			}),
+		)
+
+		assert.ErrorIs(t, err, do.ErrBodyNotReplayable)
+	})
+
+	t.Run("should log with the span of the context", func(t *testing.T) {
+		core, logs := observer.New(zapcore.DebugLevel)
+		ctx := trace.ContextWithSpanContext(context.TODO(), trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID: trace.TraceID{0x01},
+			SpanID:  trace.SpanID{0x01},
+		}))
+
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).Return(&http.Response{}, nil).Once()
+
+		err := do.Do(ctx, &url.URL{}, do.WithClient(mockClient), do.WithLogger(zap.New(core)))
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, logs.All())
+		for _, entry := range logs.All() {
+			assert.Equal(t, "01000000000000000000000000000000", entry.ContextMap()[logger.TraceIDKey])
+			assert.Equal(t, "0100000000000000", entry.ContextMap()[logger.SpanIDKey])
+		}
+	})
 }
@@ -8,4 +8,13 @@
 // options set the HTTP method to GET, utilize the default HTTP client, and
 // employ a no-operation logger. Users may override these by supplying their
 // own options when invoking Do.
+//
+// Handlers are registered by name in ordered Handlers chains: they run by
+// priority, then in registration order, and can be anchored Before or After
+// another named handler. Registering a handler under an existing name replaces it.
+// The first failing post-request handler stops the chain, so that a response
+// rejected by the status check is never decoded.
+//
+// Transient failures can be retried with WithRetry and a retry.Policy, which
+// re-sends the request with a pluggable backoff between attempts.
 package do
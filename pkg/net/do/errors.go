@@ -0,0 +1,92 @@
+package do
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// MaxErrorBodySize bounds the size of the response body kept in an HTTPError.
+const MaxErrorBodySize = 4 << 10
+
+// Sentinel errors matched by HTTPError with errors.Is, by status class or by status code.
+var (
+	ErrClientError     = errors.New("client error")
+	ErrServerError     = errors.New("server error")
+	ErrBadRequest      = errors.New("bad request")
+	ErrUnauthorized    = errors.New("unauthorized")
+	ErrForbidden       = errors.New("forbidden")
+	ErrNotFound        = errors.New("not found")
+	ErrConflict        = errors.New("conflict")
+	ErrTooManyRequests = errors.New("too many requests")
+)
+
+// ErrBodyNotReplayable is returned when a request must be sent again but its body cannot be replayed.
+var ErrBodyNotReplayable = errors.New("request body cannot be replayed")
+
+// ErrResendRequest is returned by a post-request handler to send the request once
+// more, such as after refreshing expired credentials. The request is rebuilt by
+// the pre-request handlers, and the handlers of the new response ignore the error.
+var ErrResendRequest = errors.New("request must be sent again")
+
+// statusErrors maps status codes to their sentinel errors.
+//
+//nolint:gochecknoglobals // read-only lookup table
+var statusErrors = map[int]error{
+	http.StatusBadRequest:      ErrBadRequest,
+	http.StatusUnauthorized:    ErrUnauthorized,
+	http.StatusForbidden:       ErrForbidden,
+	http.StatusNotFound:        ErrNotFound,
+	http.StatusConflict:        ErrConflict,
+	http.StatusTooManyRequests: ErrTooManyRequests,
+}
+
+// HTTPError is returned when a response has an error status code.
+type HTTPError struct {
+	// StatusCode is the response status code.
+	StatusCode int
+	// Header holds the response headers.
+	Header http.Header
+	// Body holds the beginning of the response body, at most MaxErrorBodySize bytes.
+	Body []byte
+	// Truncated reports whether the response body is larger than MaxErrorBodySize,
+	// in which case Body is incomplete and usually cannot be decoded.
+	Truncated bool
+
+	// Method is the request method.
+	Method string
+	// URL is the request URL.
+	URL string
+
+	// Err is the error decoded from the body by the ErrorDecoder, such as a *ProblemDetails.
+	Err error
+}
+
+// Error returns the request, the response status and the decoded error if any.
+func (e *HTTPError) Error() string {
+	msg := fmt.Sprintf("%s %s: %d %s", e.Method, e.URL, e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+
+	return msg
+}
+
+// Unwrap returns the error decoded from the body.
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the status code of the error matches the target, which is
+// either a status class such as ErrClientError or a status sentinel such as ErrNotFound.
+func (e *HTTPError) Is(target error) bool {
+	switch {
+	case errors.Is(target, ErrClientError):
+		return e.StatusCode >= 400 && e.StatusCode < 500
+	case errors.Is(target, ErrServerError):
+		return e.StatusCode >= 500 && e.StatusCode < 600
+	default:
+		err, ok := statusErrors[e.StatusCode]
+		return ok && errors.Is(target, err)
+	}
+}
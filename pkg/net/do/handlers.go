@@ -0,0 +1,269 @@
+package do
+
+import (
+	"context"
+	"iter"
+	"slices"
+	"time"
+)
+
+// Priorities of the built-in handlers. Handlers run in ascending priority order
+// and, for equal priorities, in insertion order.
+const (
+	// PriorityURL is used by handlers replacing the request URL, before any other
+	// handler edits it.
+	PriorityURL = 0
+	// PriorityStatusCheck is used by the response status check, before the body is consumed.
+	PriorityStatusCheck = 50
+	// PriorityBody is used by handlers producing or consuming the body.
+	PriorityBody = 100
+	// PriorityDefault is used by handlers registered without a priority.
+	PriorityDefault = 500
+	// PrioritySigning is used by handlers that must see the final request, such as signers.
+	PrioritySigning = 900
+)
+
+// Names of the built-in handlers, usable as Before and After anchors.
+// MarshalBodyHandler keeps the name the request body handler always had, which
+// is matched by existing handler observers and anchors.
+const (
+	MarshalBodyHandler   = "http_request_body_json_unmarshal"
+	MultipartHandler     = "http_request_body_multipart"
+	ContentLengthHandler = "http_request_content_length"
+	JSONRequestHandler   = "http_request_header_json"
+	HeaderHandler        = "http_request_set_header"
+	AcceptHandler        = "http_request_header_accept"
+	SignerHandler        = "http_request_signer"
+	UnmarshalBodyHandler = "http_response_body_json_unmarshal"
+	StatusCheckHandler   = "http_response_status_check"
+	SSEHandler           = "http_response_sse"
+	NDJSONStreamHandler  = "http_response_ndjson_stream"
+	ResponseIntoHandler  = "http_response_into"
+)
+
+// handlerPosition describes where a handler runs within a Handlers chain.
+type handlerPosition struct {
+	priority int
+	before   string
+	after    string
+}
+
+// HandlerOption configures the position of a handler within a Handlers chain.
+type HandlerOption func(pos *handlerPosition)
+
+// apply sets the given HandlerOption to the position.
+func (o HandlerOption) apply(pos *handlerPosition) {
+	o(pos)
+}
+
+// Priority sets the priority of the handler, lower priorities run first.
+func Priority(priority int) HandlerOption {
+	return func(pos *handlerPosition) {
+		pos.priority = priority
+	}
+}
+
+// Before runs the handler right before the named handler, when it is registered.
+func Before(name string) HandlerOption {
+	return func(pos *handlerPosition) {
+		pos.before = name
+		pos.after = ""
+	}
+}
+
+// After runs the handler right after the named handler, when it is registered.
+func After(name string) HandlerOption {
+	return func(pos *handlerPosition) {
+		pos.after = name
+		pos.before = ""
+	}
+}
+
+// handlerEntry is a named handler registered in a Handlers chain.
+type handlerEntry[T any] struct {
+	handlerPosition
+
+	name    string
+	handler T
+	seq     int
+}
+
+// Handlers is an ordered chain of named handlers. Registering a handler under an
+// existing name replaces it, and the chain is always iterated in the same order:
+// by priority, then by insertion order, with Before and After anchors applied last.
+type Handlers[T any] struct {
+	entries []handlerEntry[T]
+	seq     int
+}
+
+// NewHandlers creates an empty Handlers chain.
+func NewHandlers[T any]() *Handlers[T] {
+	return &Handlers[T]{}
+}
+
+// Set registers the handler under the given name, replacing any handler with the
+// same name. A replaced handler keeps its insertion order.
+func (h *Handlers[T]) Set(name string, handler T, opts ...HandlerOption) {
+	pos := handlerPosition{priority: PriorityDefault}
+	for _, opt := range opts {
+		opt.apply(&pos)
+	}
+
+	for i := range h.entries {
+		if h.entries[i].name == name {
+			h.entries[i].handler = handler
+			h.entries[i].handlerPosition = pos
+			return
+		}
+	}
+
+	h.seq++
+	h.entries = append(h.entries, handlerEntry[T]{handlerPosition: pos, name: name, handler: handler, seq: h.seq})
+}
+
+// Get returns the handler registered under the given name.
+func (h *Handlers[T]) Get(name string) (T, bool) {
+	for _, e := range h.entries {
+		if e.name == name {
+			return e.handler, true
+		}
+	}
+
+	var zero T
+	return zero, false
+}
+
+// Delete removes the handler registered under the given name.
+func (h *Handlers[T]) Delete(name string) {
+	h.entries = slices.DeleteFunc(h.entries, func(e handlerEntry[T]) bool {
+		return e.name == name
+	})
+}
+
+// Len returns the number of registered handlers.
+func (h *Handlers[T]) Len() int {
+	return len(h.entries)
+}
+
+// All returns an iterator over the handlers and their names, in execution order.
+func (h *Handlers[T]) All() iter.Seq2[string, T] {
+	return func(yield func(string, T) bool) {
+		for _, e := range h.ordered() {
+			if !yield(e.name, e.handler) {
+				return
+			}
+		}
+	}
+}
+
+// ordered returns the entries sorted by priority and insertion order, with the
+// anchored entries placed next to their anchors. Anchors are resolved as a tree,
+// so that an entry anchored to another anchored entry follows it; entries whose
+// anchor is missing or forms a cycle keep their sorted position.
+func (h *Handlers[T]) ordered() []handlerEntry[T] {
+	entries := slices.Clone(h.entries)
+	slices.SortStableFunc(entries, func(a, b handlerEntry[T]) int {
+		if a.priority != b.priority {
+			return a.priority - b.priority
+		}
+		return a.seq - b.seq
+	})
+
+	index := make(map[string]int, len(entries))
+	for i, e := range entries {
+		index[e.name] = i
+	}
+
+	parent := func(i int) int {
+		anchor := entries[i].before + entries[i].after
+		if p, ok := index[anchor]; ok && p != i {
+			return p
+		}
+		return -1
+	}
+
+	// anchoredTo is the anchor of every entry, -1 for the entries kept in place.
+	anchoredTo := make([]int, len(entries))
+	for i := range entries {
+		anchoredTo[i] = parent(i)
+
+		for p, steps := anchoredTo[i], 0; p >= 0 && steps < len(entries); p, steps = parent(p), steps+1 {
+			if p == i {
+				anchoredTo[i] = -1
+				break
+			}
+		}
+	}
+
+	ordered := make([]handlerEntry[T], 0, len(entries))
+
+	var visit func(i int)
+	visit = func(i int) {
+		for j := range entries {
+			if anchoredTo[j] == i && entries[j].before != "" {
+				visit(j)
+			}
+		}
+
+		ordered = append(ordered, entries[i])
+
+		for j := range entries {
+			if anchoredTo[j] == i && entries[j].after != "" {
+				visit(j)
+			}
+		}
+	}
+
+	for i := range entries {
+		if anchoredTo[i] < 0 {
+			visit(i)
+		}
+	}
+
+	return ordered
+}
+
+// Kinds of the handlers reported to a HandlerObserver.
+const (
+	PreRequestHandlerKind  = "pre_request"
+	PostRequestHandlerKind = "post_request"
+	ErrorHandlerKind       = "error"
+)
+
+// HandlerEvent describes the run of a named handler by Do.
+type HandlerEvent struct {
+	// Kind is the kind of the handler, such as PreRequestHandlerKind.
+	Kind string
+	// Name is the name the handler is registered with.
+	Name string
+	// Start is when the handler started.
+	Start time.Time
+	// Duration is how long the handler ran.
+	Duration time.Duration
+	// Err is the error returned by the handler.
+	Err error
+}
+
+// HandlerObserver is notified of every handler run by Do, for instance to
+// measure the cost of the handlers.
+type HandlerObserver func(ctx context.Context, event HandlerEvent)
+
+// WithHandlerObserver adds an observer notified of every handler run by Do.
+func WithHandlerObserver(observer HandlerObserver) Option {
+	return func(params *Params) {
+		params.HandlerObservers = append(params.HandlerObservers, observer)
+	}
+}
+
+// observeHandler notifies the handler observers of a handler which started at start.
+func (p *Params) observeHandler(ctx context.Context, kind, name string, start time.Time, err error) {
+	if len(p.HandlerObservers) == 0 {
+		return
+	}
+
+	event := HandlerEvent{Kind: kind, Name: name, Start: start, Duration: p.now().Sub(start), Err: err}
+
+	for _, observer := range p.HandlerObservers {
+		observer(ctx, event)
+	}
+}
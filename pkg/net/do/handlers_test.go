@@ -0,0 +1,157 @@
+package do_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/merlindorin/go-shared/pkg/net/do"
+)
+
+func names[T any](h *do.Handlers[T]) []string {
+	var got []string
+	for name := range h.All() {
+		got = append(got, name)
+	}
+	return got
+}
+
+func TestHandlers(t *testing.T) {
+	t.Run("should iterate in insertion order for equal priorities", func(t *testing.T) {
+		h := do.NewHandlers[int]()
+		h.Set("c", 0)
+		h.Set("a", 0)
+		h.Set("b", 0)
+
+		for range 10 {
+			assert.Equal(t, []string{"c", "a", "b"}, names(h))
+		}
+	})
+
+	t.Run("should iterate by ascending priority", func(t *testing.T) {
+		h := do.NewHandlers[int]()
+		h.Set("sign", 0, do.Priority(do.PrioritySigning))
+		h.Set("header", 0)
+		h.Set("body", 0, do.Priority(do.PriorityBody))
+
+		assert.Equal(t, []string{"body", "header", "sign"}, names(h))
+	})
+
+	t.Run("should honour before and after anchors", func(t *testing.T) {
+		h := do.NewHandlers[int]()
+		h.Set("a", 0)
+		h.Set("b", 0)
+		h.Set("c", 0)
+		h.Set("before-a", 0, do.Before("a"))
+		h.Set("after-a", 0, do.After("a"))
+		h.Set("missing", 0, do.After("unknown"))
+
+		assert.Equal(t, []string{"before-a", "a", "after-a", "b", "c", "missing"}, names(h))
+	})
+
+	t.Run("should resolve chained anchors", func(t *testing.T) {
+		h := do.NewHandlers[int]()
+		h.Set("x", 0, do.After("y"))
+		h.Set("a", 0)
+		h.Set("y", 0, do.After("w"))
+		h.Set("w", 0)
+		h.Set("v", 0, do.Before("y"))
+
+		assert.Equal(t, []string{"a", "w", "v", "y", "x"}, names(h))
+	})
+
+	t.Run("should keep anchor cycles in place", func(t *testing.T) {
+		h := do.NewHandlers[int]()
+		h.Set("a", 0, do.After("b"))
+		h.Set("b", 0, do.After("a"))
+		h.Set("c", 0, do.After("a"))
+
+		assert.Equal(t, []string{"a", "c", "b"}, names(h))
+	})
+
+	t.Run("should replace handlers by name and keep their insertion order", func(t *testing.T) {
+		h := do.NewHandlers[int]()
+		h.Set("a", 1)
+		h.Set("b", 2)
+		h.Set("a", 3)
+
+		got, ok := h.Get("a")
+		assert.True(t, ok)
+		assert.Equal(t, 3, got)
+		assert.Equal(t, []string{"a", "b"}, names(h))
+
+		h.Delete("a")
+		assert.Equal(t, 1, h.Len())
+	})
+}
+
+func TestDoHandlersOrder(t *testing.T) {
+	t.Run("should run the body marshalling before the other pre-request handlers", func(t *testing.T) {
+		var order []string
+		record := func(name string) do.PreRequestHandlerFunc {
+			return func(_ context.Context, _ *http.Request) error {
+				order = append(order, name)
+				return nil
+			}
+		}
+
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).Return(&http.Response{}, nil).Once()
+
+		err := do.Do(
+			context.TODO(),
+			&url.URL{},
+			do.WithClient(mockClient),
+			do.WithPreRequestHandler("sign", record("sign"), do.Priority(do.PrioritySigning)),
+			do.WithPreRequestHandler("header", record("header")),
+			do.WithMarshalBody(map[string]string{}),
+			do.WithPreRequestHandler("after-body", record("after-body"), do.After(do.MarshalBodyHandler)),
+		)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"after-body", "header", "sign"}, order)
+	})
+}
+
+func TestWithHandlerObserver(t *testing.T) {
+	t.Run("should report every handler run", func(t *testing.T) {
+		var events []do.HandlerEvent
+
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).Return(&http.Response{StatusCode: http.StatusOK}, nil).Once()
+
+		failure := errors.New("failure")
+
+		err := do.Do(
+			context.TODO(),
+			&url.URL{},
+			do.WithClient(mockClient),
+			do.WithExtraHeader("X-Test", "test"),
+			do.WithPostRequestHandler("failing", func(context.Context, *http.Request, *http.Response) error {
+				return failure
+			}),
+			do.WithErrorHandler("passthrough", func(_ context.Context, _ *http.Request, _ *http.Response, err error) error {
+				return err
+			}),
+			do.WithHandlerObserver(func(_ context.Context, event do.HandlerEvent) {
+				events = append(events, event)
+			}),
+		)
+
+		assert.ErrorIs(t, err, failure)
+		assert.Len(t, events, 3)
+		assert.Equal(t, do.PreRequestHandlerKind, events[0].Kind)
+		assert.Equal(t, "http_request_set_header_X-Test", events[0].Name)
+		assert.NoError(t, events[0].Err)
+		assert.Equal(t, do.PostRequestHandlerKind, events[1].Kind)
+		assert.Equal(t, "failing", events[1].Name)
+		assert.ErrorIs(t, events[1].Err, failure)
+		assert.Equal(t, do.ErrorHandlerKind, events[2].Kind)
+		assert.Equal(t, "passthrough", events[2].Name)
+	})
+}
@@ -0,0 +1,207 @@
+package do
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// WithHedging sends hedged requests: when no response arrived after delay, or as
+// soon as an attempt fails, an identical request is sent, up to maxAttempts
+// requests in total. The first successful response, which is any response below
+// 500, is kept and the other requests are cancelled through their context.
+//
+// Hedges are sent to the given endpoints, skipping the host of the original
+// request, by replacing the scheme and host of the request URL. Without
+// endpoints, hedges are sent to the same host. The attempt which won is logged.
+//
+// Only idempotent requests are hedged: GET, HEAD, OPTIONS, TRACE, PUT and DELETE
+// requests, and requests with an Idempotency-Key header. Requests with a body
+// are not hedged either, as concurrent attempts cannot share it.
+func WithHedging(delay time.Duration, maxAttempts int, endpoints ...*url.URL) Option {
+	return func(params *Params) {
+		WithClientDecorator(func(next HTTPClientDoer) HTTPClientDoer {
+			return &hedgingClient{
+				next:        next,
+				params:      params,
+				delay:       delay,
+				maxAttempts: maxAttempts,
+				endpoints:   endpoints,
+			}
+		}).Apply(params)
+	}
+}
+
+// hedgingClient is an HTTPClientDoer sending hedged requests.
+type hedgingClient struct {
+	next        HTTPClientDoer
+	params      *Params
+	delay       time.Duration
+	maxAttempts int
+	endpoints   []*url.URL
+}
+
+// hedgeResult is the outcome of a hedged attempt.
+type hedgeResult struct {
+	attempt int
+	req     *http.Request
+	res     *http.Response
+	err     error
+}
+
+// succeeded reports whether the attempt ended with a response to keep.
+func (r hedgeResult) succeeded() bool {
+	return r.err == nil && r.res.StatusCode < http.StatusInternalServerError
+}
+
+// Do sends the request and its hedges, returning the first successful response.
+func (c *hedgingClient) Do(req *http.Request) (*http.Response, error) {
+	if c.maxAttempts <= 1 || !isHedgeable(req) {
+		return c.next.Do(req)
+	}
+
+	log := c.params.Logger
+	results := make(chan hedgeResult, c.maxAttempts)
+	cancels := make([]context.CancelFunc, 0, c.maxAttempts)
+
+	send := func() {
+		attempt := len(cancels)
+		ctx, cancel := context.WithCancel(req.Context())
+		cancels = append(cancels, cancel)
+
+		go func() {
+			r, err := c.attempt(ctx, req, attempt)
+			if err != nil {
+				results <- hedgeResult{attempt: attempt, err: err}
+				return
+			}
+
+			if attempt > 0 {
+				log.Debug("sendHedgedRequest", zap.Int("hedgeAttempt", attempt), zap.String("host", r.URL.Host))
+			}
+
+			res, err := c.next.Do(r) //nolint:bodyclose // it is managed by the caller
+			results <- hedgeResult{attempt: attempt, req: r, res: res, err: err}
+		}()
+	}
+
+	send()
+
+	timer := time.NewTimer(c.delay)
+	defer timer.Stop()
+
+	var (
+		last     hedgeResult
+		received int
+	)
+
+	for received < len(cancels) {
+		select {
+		case <-timer.C:
+			if len(cancels) < c.maxAttempts {
+				send()
+				timer.Reset(c.delay)
+			}
+			continue
+		case last = <-results:
+			received++
+		}
+
+		if last.succeeded() {
+			break
+		}
+
+		canHedge := len(cancels) < c.maxAttempts && req.Context().Err() == nil
+		if received < len(cancels) || canHedge {
+			discardBody(last.res, log)
+			last.res = nil
+		}
+
+		if canHedge {
+			send()
+			timer.Reset(c.delay)
+		}
+	}
+
+	// Cancel the attempts still in flight and discard their responses.
+	for i, cancel := range cancels {
+		if i != last.attempt {
+			cancel()
+		}
+	}
+
+	go func(pending int) {
+		for range pending {
+			discardBody((<-results).res, log)
+		}
+	}(len(cancels) - received)
+
+	if last.err != nil {
+		cancels[last.attempt]()
+		return nil, last.err
+	}
+
+	log.Debug("hedgedRequestWon", zap.Int("hedgeAttempt", last.attempt), zap.String("host", last.req.URL.Host))
+
+	if last.res.Body == nil {
+		cancels[last.attempt]()
+		return last.res, nil
+	}
+
+	last.res.Body = &cancelingBody{ReadCloser: last.res.Body, cancel: cancels[last.attempt]}
+
+	return last.res, nil
+}
+
+// isHedgeable reports whether the request can be sent several times concurrently.
+func isHedgeable(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody {
+		return false
+	}
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return req.Header.Get("Idempotency-Key") != ""
+	}
+}
+
+// attempt returns the request of the given attempt, sent to the next endpoint for hedges.
+func (c *hedgingClient) attempt(ctx context.Context, req *http.Request, attempt int) (*http.Request, error) {
+	r, err := CloneRequest(ctx, req)
+	if err != nil || attempt == 0 {
+		return r, err
+	}
+
+	endpoints := make([]*url.URL, 0, len(c.endpoints))
+	for _, endpoint := range c.endpoints {
+		if endpoint.Host != req.URL.Host {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+
+	if len(endpoints) > 0 {
+		endpoint := endpoints[(attempt-1)%len(endpoints)]
+		r.URL.Scheme, r.URL.Host, r.Host = endpoint.Scheme, endpoint.Host, ""
+	}
+
+	return r, nil
+}
+
+// cancelingBody cancels the context of its request once closed.
+type cancelingBody struct {
+	io.ReadCloser
+
+	cancel context.CancelFunc
+}
+
+// Close closes the body and cancels the context of the request.
+func (b *cancelingBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,108 @@
+package do_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/merlindorin/go-shared/pkg/must"
+	"github.com/merlindorin/go-shared/pkg/net/do"
+)
+
+func TestWithHedging(t *testing.T) {
+	u := must.Get(url.Parse("http://primary/items"))
+	replica := must.Get(url.Parse("http://replica"))
+
+	t.Run("should send a hedge to another endpoint after the delay and keep the first response", func(t *testing.T) {
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.MatchedBy(func(req *http.Request) bool {
+			return req.URL.Host == "primary"
+		})).RunAndReturn(func(req *http.Request) (*http.Response, error) {
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		}).Once()
+		mockClient.EXPECT().Do(mock.MatchedBy(func(req *http.Request) bool {
+			return req.URL.Host == "replica" && req.URL.Path == "/items"
+		})).Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"name":"item"}`)),
+		}, nil).Once()
+
+		var got item
+		err := do.Do(t.Context(), u,
+			do.WithClient(mockClient),
+			do.WithHedging(10*time.Millisecond, 2, u, replica),
+			do.WithUnmarshalBody(&got),
+		)
+
+		assert.NoError(t, err)
+		assert.Equal(t, item{Name: "item"}, got)
+	})
+
+	t.Run("should not hedge when the first response is fast", func(t *testing.T) {
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader("")),
+		}, nil).Once()
+
+		assert.NoError(t, do.Do(t.Context(), u, do.WithClient(mockClient), do.WithHedging(time.Second, 3)))
+	})
+
+	t.Run("should hedge immediately on failure and return the last failure", func(t *testing.T) {
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).Return(nil, errors.New("connection refused")).Times(3)
+
+		err := do.Do(t.Context(), u, do.WithClient(mockClient), do.WithHedging(time.Hour, 3))
+		assert.ErrorContains(t, err, "connection refused")
+	})
+
+	t.Run("should not hedge requests which are not idempotent", func(t *testing.T) {
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).Return(nil, errors.New("connection refused")).Times(2)
+
+		hedging := do.WithHedging(time.Hour, 3)
+
+		err := do.Do(t.Context(), u, do.WithClient(mockClient), do.WithMethod(http.MethodPost), hedging)
+		assert.ErrorContains(t, err, "connection refused")
+
+		err = do.Do(t.Context(), u, do.WithClient(mockClient), do.WithMethod(http.MethodPut), hedging,
+			do.WithBody(strings.NewReader("payload")))
+		assert.ErrorContains(t, err, "connection refused")
+	})
+
+	t.Run("should hedge requests with an idempotency key", func(t *testing.T) {
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).Return(nil, errors.New("connection refused")).Times(2)
+
+		err := do.Do(t.Context(), u,
+			do.WithClient(mockClient),
+			do.WithMethod(http.MethodPost),
+			do.WithExtraHeader("Idempotency-Key", "key"),
+			do.WithHedging(time.Hour, 2),
+		)
+		assert.ErrorContains(t, err, "connection refused")
+	})
+
+	t.Run("should release the winning attempt of a response without body", func(t *testing.T) {
+		var winner *http.Request
+
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).RunAndReturn(func(req *http.Request) (*http.Response, error) {
+			winner = req
+			return &http.Response{StatusCode: http.StatusNoContent}, nil
+		}).Once()
+
+		assert.NoError(t, do.Do(t.Context(), u, do.WithClient(mockClient), do.WithHedging(time.Hour, 2)))
+		assert.ErrorIs(t, winner.Context().Err(), context.Canceled)
+	})
+}
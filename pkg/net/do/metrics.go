@@ -0,0 +1,157 @@
+package do
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics holds the Prometheus metrics of HTTP client requests.
+type Metrics struct {
+	requests      *prometheus.CounterVec
+	duration      *prometheus.HistogramVec
+	inFlight      *prometheus.GaugeVec
+	sentBytes     *prometheus.CounterVec
+	receivedBytes *prometheus.CounterVec
+}
+
+// NewMetrics creates the metrics of HTTP client requests and registers them
+// with registerer. Metrics already registered are reused, so that every client
+// of a process shares them.
+func NewMetrics(registerer prometheus.Registerer) (*Metrics, error) {
+	labels := []string{"method", "host", "route"}
+
+	m := &Metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_client_requests_total",
+			Help: "Number of HTTP client requests by status class, error for transport errors.",
+		}, append(labels, "status_class")),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_client_request_duration_seconds",
+			Help:    "Duration of HTTP client requests, until the response body is read or closed.",
+			Buckets: prometheus.DefBuckets,
+		}, append(labels, "status_class")),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "http_client_requests_in_flight",
+			Help: "Number of HTTP client requests in flight.",
+		}, labels),
+		sentBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_client_request_sent_bytes_total",
+			Help: "Number of bytes sent in HTTP client request bodies of known length.",
+		}, labels),
+		receivedBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_client_response_received_bytes_total",
+			Help: "Number of bytes read from HTTP client response bodies.",
+		}, labels),
+	}
+
+	var err error
+
+	m.requests, err = register(registerer, m.requests)
+	if err == nil {
+		m.duration, err = register(registerer, m.duration)
+	}
+	if err == nil {
+		m.inFlight, err = register(registerer, m.inFlight)
+	}
+	if err == nil {
+		m.sentBytes, err = register(registerer, m.sentBytes)
+	}
+	if err == nil {
+		m.receivedBytes, err = register(registerer, m.receivedBytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// register registers c with registerer, returning the collector already registered if any.
+func register[C prometheus.Collector](registerer prometheus.Registerer, c C) (C, error) {
+	err := registerer.Register(c)
+
+	var already prometheus.AlreadyRegisteredError
+	if errors.As(err, &already) {
+		if existing, ok := already.ExistingCollector.(C); ok {
+			return existing, nil
+		}
+	}
+
+	return c, err
+}
+
+// Option returns an Option recording Prometheus metrics for every attempt of the
+// request: request counts by method, host, route and status class, durations,
+// requests in flight and bytes sent and received. The route is the format given
+// to WithPath rather than the expanded path, which keeps the cardinality under
+// control.
+func (m *Metrics) Option() Option {
+	return func(params *Params) {
+		WithClientDecorator(func(next HTTPClientDoer) HTTPClientDoer {
+			return &metricsClient{next: next, params: params, metrics: m}
+		}).Apply(params)
+	}
+}
+
+// metricsClient is an HTTPClientDoer recording metrics.
+type metricsClient struct {
+	next    HTTPClientDoer
+	params  *Params
+	metrics *Metrics
+}
+
+// Do sends the request and records its metrics once the response body is read to
+// the end or closed.
+func (c *metricsClient) Do(req *http.Request) (*http.Response, error) {
+	labels := prometheus.Labels{
+		"method": req.Method,
+		"host":   req.URL.Host,
+		"route":  c.params.PathTemplate,
+	}
+
+	start := c.params.now()
+	inFlight := c.metrics.inFlight.With(labels)
+	inFlight.Inc()
+
+	if req.ContentLength > 0 {
+		c.metrics.sentBytes.With(labels).Add(float64(req.ContentLength))
+	}
+
+	res, err := c.next.Do(req)
+	if err != nil || res.Body == nil {
+		inFlight.Dec()
+		c.observe(labels, res, start, 0)
+		return res, err
+	}
+
+	res.Body = NewCountingBody(res.Body, func(read int64) {
+		inFlight.Dec()
+		c.observe(labels, res, start, read)
+	})
+
+	return res, nil
+}
+
+// observe records a finished request.
+func (c *metricsClient) observe(labels prometheus.Labels, res *http.Response, start time.Time, received int64) {
+	c.metrics.receivedBytes.With(labels).Add(float64(received))
+
+	statusClass := "error"
+	if res != nil {
+		statusClass = strconv.Itoa(res.StatusCode/100) + "xx"
+	}
+
+	labels = prometheus.Labels{
+		"method":       labels["method"],
+		"host":         labels["host"],
+		"route":        labels["route"],
+		"status_class": statusClass,
+	}
+
+	c.metrics.requests.With(labels).Inc()
+	c.metrics.duration.With(labels).Observe(c.params.now().Sub(start).Seconds())
+}
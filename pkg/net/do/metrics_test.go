@@ -0,0 +1,113 @@
+package do_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/merlindorin/go-shared/pkg/must"
+	"github.com/merlindorin/go-shared/pkg/net/do"
+)
+
+func TestMetrics(t *testing.T) {
+	u := must.Get(url.Parse("http://localhost"))
+	registry := prometheus.NewRegistry()
+	metrics := must.Get(do.NewMetrics(registry))
+
+	mockClient := do.NewMockHttpClientDoer(t)
+	mockClient.EXPECT().Do(mock.MatchedBy(func(req *http.Request) bool {
+		return req.URL.Path == "/users/1"
+	})).Return(&http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("hello"))}, nil).Once()
+	mockClient.EXPECT().Do(mock.MatchedBy(func(req *http.Request) bool {
+		return req.URL.Path == "/users/2"
+	})).Return(&http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader(""))}, nil).Once()
+
+	var body string
+	err := do.Do(t.Context(), u,
+		do.WithClient(mockClient),
+		metrics.Option(),
+		do.WithPath("/users/%d", 1),
+		do.WithMethod(http.MethodPut),
+		do.WithBody(strings.NewReader("hi")),
+		do.WithContentLength([]byte("hi")),
+		do.WithPostRequestHandler("read", func(_ context.Context, _ *http.Request, res *http.Response) error {
+			b, err := io.ReadAll(res.Body)
+			body = string(b)
+			return err
+		}),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", body)
+
+	err = do.Do(t.Context(), u, do.WithClient(mockClient), metrics.Option(), do.WithPath("/users/%d", 2))
+	assert.NoError(t, err)
+
+	want := `
+# HELP http_client_requests_total Number of HTTP client requests by status class, error for transport errors.
+# TYPE http_client_requests_total counter
+http_client_requests_total{host="localhost",method="GET",route="/users/%d",status_class="4xx"} 1
+http_client_requests_total{host="localhost",method="PUT",route="/users/%d",status_class="2xx"} 1
+# HELP http_client_requests_in_flight Number of HTTP client requests in flight.
+# TYPE http_client_requests_in_flight gauge
+http_client_requests_in_flight{host="localhost",method="GET",route="/users/%d"} 0
+http_client_requests_in_flight{host="localhost",method="PUT",route="/users/%d"} 0
+# HELP http_client_request_sent_bytes_total Number of bytes sent in HTTP client request bodies of known length.
+# TYPE http_client_request_sent_bytes_total counter
+http_client_request_sent_bytes_total{host="localhost",method="PUT",route="/users/%d"} 2
+# HELP http_client_response_received_bytes_total Number of bytes read from HTTP client response bodies.
+# TYPE http_client_response_received_bytes_total counter
+http_client_response_received_bytes_total{host="localhost",method="GET",route="/users/%d"} 0
+http_client_response_received_bytes_total{host="localhost",method="PUT",route="/users/%d"} 5
+`
+
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(want),
+		"http_client_requests_total",
+		"http_client_requests_in_flight",
+		"http_client_request_sent_bytes_total",
+		"http_client_response_received_bytes_total",
+	))
+	assert.Equal(t, 2, testutil.CollectAndCount(registry, "http_client_request_duration_seconds"))
+}
+
+func TestMetricsWithUnmarshalBody(t *testing.T) {
+	u := must.Get(url.Parse("http://localhost"))
+	registry := prometheus.NewRegistry()
+	metrics := must.Get(do.NewMetrics(registry))
+
+	mockClient := do.NewMockHttpClientDoer(t)
+	mockClient.EXPECT().Do(mock.Anything).
+		Return(&http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{"name":"item"}`))}, nil).
+		Once()
+
+	var got item
+	err := do.Do(t.Context(), u, do.WithClient(mockClient), metrics.Option(), do.WithUnmarshalBody(&got))
+	assert.NoError(t, err)
+	assert.Equal(t, item{Name: "item"}, got)
+
+	want := `
+# HELP http_client_requests_total Number of HTTP client requests by status class, error for transport errors.
+# TYPE http_client_requests_total counter
+http_client_requests_total{host="localhost",method="GET",route="",status_class="2xx"} 1
+# HELP http_client_requests_in_flight Number of HTTP client requests in flight.
+# TYPE http_client_requests_in_flight gauge
+http_client_requests_in_flight{host="localhost",method="GET",route=""} 0
+# HELP http_client_response_received_bytes_total Number of bytes read from HTTP client response bodies.
+# TYPE http_client_response_received_bytes_total counter
+http_client_response_received_bytes_total{host="localhost",method="GET",route=""} 15
+`
+
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(want),
+		"http_client_requests_total",
+		"http_client_requests_in_flight",
+		"http_client_response_received_bytes_total",
+	))
+	assert.Equal(t, 1, testutil.CollectAndCount(registry, "http_client_request_duration_seconds"))
+}
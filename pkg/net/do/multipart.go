@@ -0,0 +1,247 @@
+package do
+
+import (
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strings"
+	"sync"
+)
+
+// Part is a part of a multipart/form-data body. Parts implementing
+// ReplayablePart can be written several times, other parts are written once.
+type Part interface {
+	// WritePart writes the part to the multipart writer.
+	WritePart(w *multipart.Writer) error
+}
+
+// ReplayablePart is a Part that reports whether it can be written several times,
+// which is needed to send the body again on retries and redirects.
+type ReplayablePart interface {
+	Part
+
+	// Replayable reports whether the part can be written several times.
+	Replayable() bool
+}
+
+// ProgressFunc is called with the number of body bytes written so far.
+type ProgressFunc func(written int64)
+
+// MultipartField is a form field part.
+type MultipartField struct {
+	Name  string
+	Value string
+}
+
+// WritePart writes the field.
+func (f MultipartField) WritePart(w *multipart.Writer) error {
+	return w.WriteField(f.Name, f.Value)
+}
+
+// Replayable returns true, a field can always be written again.
+func (f MultipartField) Replayable() bool {
+	return true
+}
+
+// MultipartFile is a file part streamed from Reader. Readers implementing
+// io.Seeker are rewound before being streamed, so that the body can be replayed.
+// Other readers are streamed once: sending the body again, for a retry or a
+// redirect, fails with ErrBodyNotReplayable.
+type MultipartFile struct {
+	// Field is the form field name.
+	Field string
+	// Filename is the name of the file.
+	Filename string
+	// ContentType is the media type of the file, application/octet-stream when empty.
+	ContentType string
+	// Reader provides the file content.
+	Reader io.Reader
+}
+
+// WritePart streams the file.
+func (f MultipartFile) WritePart(w *multipart.Writer) error {
+	contentType := f.ContentType
+	if contentType == "" {
+		contentType = OctetStreamContentType
+	}
+
+	h := textproto.MIMEHeader{}
+	h.Set("Content-Disposition", `form-data; name="`+escapeQuotes(f.Field)+`"; filename="`+escapeQuotes(f.Filename)+`"`)
+	h.Set("Content-Type", contentType)
+
+	part, err := w.CreatePart(h)
+	if err != nil {
+		return err
+	}
+
+	if seeker, ok := f.Reader.(io.Seeker); ok {
+		if _, err = seeker.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+	}
+
+	_, err = io.Copy(part, f.Reader)
+	return err
+}
+
+// Replayable reports whether the reader implements io.Seeker.
+func (f MultipartFile) Replayable() bool {
+	_, ok := f.Reader.(io.Seeker)
+	return ok
+}
+
+// WithUploadProgress reports the progress of the body written by WithMultipart.
+func WithUploadProgress(fn ProgressFunc) Option {
+	return func(params *Params) {
+		params.UploadProgress = fn
+	}
+}
+
+// WithMultipart sets a multipart/form-data body made of the given parts, along
+// with its Content-Type and boundary. The body is streamed through an io.Pipe,
+// files are never buffered in memory. Sending the body again closes the previous
+// stream first, so that two streams never read the same part, and fails with
+// ErrBodyNotReplayable when a part is not a replayable ReplayablePart. Signers
+// reject such streaming bodies.
+func WithMultipart(parts ...Part) Option {
+	return func(params *Params) {
+		streams := &multipartStreams{parts: parts}
+
+		WithPreRequestHandler(
+			MultipartHandler,
+			func(ctx context.Context, req *http.Request) error {
+				boundary := multipart.NewWriter(io.Discard).Boundary()
+
+				body, err := streams.open(ctx, boundary, params.UploadProgress)
+				if err != nil {
+					return err
+				}
+
+				req.Header.Set("Content-Type", "multipart/form-data; boundary="+boundary)
+				req.Body = body
+				req.GetBody = func() (io.ReadCloser, error) {
+					return streams.open(ctx, boundary, params.UploadProgress)
+				}
+				req.ContentLength = -1
+
+				return nil
+			},
+			Priority(PriorityBody),
+		).Apply(params)
+	}
+}
+
+// multipartStreams opens the streams of a multipart body, one at a time.
+type multipartStreams struct {
+	parts []Part
+
+	mu     sync.Mutex
+	opened bool
+	last   *io.PipeReader
+	done   <-chan struct{}
+}
+
+// open closes the previous stream and waits for its writer to stop, then opens
+// a new stream. It fails when a stream was already opened and a part cannot be
+// written again.
+func (s *multipartStreams) open(ctx context.Context, boundary string, progress ProgressFunc) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.opened && !replayable(s.parts) {
+		return nil, ErrBodyNotReplayable
+	}
+
+	if s.last != nil {
+		_ = s.last.CloseWithError(ErrBodyNotReplayable)
+		<-s.done
+	}
+
+	s.opened = true
+	s.last, s.done = streamMultipart(ctx, boundary, s.parts, progress)
+
+	return s.last, nil
+}
+
+// replayable reports whether every part can be written again.
+func replayable(parts []Part) bool {
+	for _, part := range parts {
+		if p, ok := part.(ReplayablePart); !ok || !p.Replayable() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// streamMultipart writes the parts into a pipe from a goroutine and returns its
+// reading end, along with a channel closed once the writer stopped. The writer
+// stops when the reader is closed or ctx is done.
+func streamMultipart(
+	ctx context.Context,
+	boundary string,
+	parts []Part,
+	progress ProgressFunc,
+) (*io.PipeReader, <-chan struct{}) {
+	pr, pw := io.Pipe()
+
+	var w io.Writer = pw
+	if progress != nil {
+		w = &progressWriter{w: pw, progress: progress}
+	}
+
+	done := make(chan struct{})
+
+	mw := multipart.NewWriter(w)
+	if err := mw.SetBoundary(boundary); err != nil {
+		_ = pw.CloseWithError(err)
+		close(done)
+		return pr, done
+	}
+
+	stop := context.AfterFunc(ctx, func() {
+		_ = pr.CloseWithError(ctx.Err())
+	})
+
+	go func() {
+		defer close(done)
+		defer stop()
+
+		var err error
+		for _, part := range parts {
+			if err = part.WritePart(mw); err != nil {
+				break
+			}
+		}
+
+		if err == nil {
+			err = mw.Close()
+		}
+
+		_ = pw.CloseWithError(err)
+	}()
+
+	return pr, done
+}
+
+// progressWriter reports the number of bytes written through it.
+type progressWriter struct {
+	w        io.Writer
+	written  int64
+	progress ProgressFunc
+}
+
+// Write writes p and reports the progress.
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.written += int64(n)
+	p.progress(p.written)
+	return n, err
+}
+
+// escapeQuotes escapes a Content-Disposition parameter value.
+func escapeQuotes(s string) string {
+	return strings.NewReplacer("\\", "\\\\", `"`, "\\\"").Replace(s)
+}
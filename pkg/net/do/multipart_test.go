@@ -0,0 +1,113 @@
+package do_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/merlindorin/go-shared/pkg/must"
+	"github.com/merlindorin/go-shared/pkg/net/do"
+)
+
+func TestMultipart(t *testing.T) {
+	t.Run("should stream fields and files", func(t *testing.T) {
+		firmware := strings.Repeat("firmware", 1<<14)
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, int64(-1), r.ContentLength)
+			assert.NoError(t, r.ParseMultipartForm(1<<20))
+			assert.Equal(t, "1.2.3", r.FormValue("version"))
+
+			f, h, err := r.FormFile("image")
+			assert.NoError(t, err)
+			assert.Equal(t, "image.bin", h.Filename)
+			assert.Equal(t, do.OctetStreamContentType, h.Header.Get("Content-Type"))
+			assert.Equal(t, firmware, string(must.Get(io.ReadAll(f))))
+
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer srv.Close()
+
+		var written int64
+		err := do.Do(
+			context.TODO(),
+			must.Get(url.Parse(srv.URL)),
+			do.WithMethod(http.MethodPost),
+			do.WithStatusCheck(),
+			do.WithMultipart(
+				do.MultipartField{Name: "version", Value: "1.2.3"},
+				do.MultipartFile{Field: "image", Filename: "image.bin", Reader: strings.NewReader(firmware)},
+			),
+			do.WithUploadProgress(func(n int64) { written = n }),
+		)
+
+		assert.NoError(t, err)
+		assert.Greater(t, written, int64(len(firmware)))
+	})
+
+	redirecting := func(t *testing.T, received *string) *httptest.Server {
+		t.Helper()
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/upload" {
+				_, _ = io.Copy(io.Discard, r.Body)
+				http.Redirect(w, r, "/moved", http.StatusTemporaryRedirect)
+				return
+			}
+
+			f, _, err := r.FormFile("image")
+			if assert.NoError(t, err) {
+				*received = string(must.Get(io.ReadAll(f)))
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		t.Cleanup(srv.Close)
+
+		return srv
+	}
+
+	t.Run("should replay seekable files on redirects", func(t *testing.T) {
+		var received string
+		srv := redirecting(t, &received)
+
+		err := do.Do(
+			context.TODO(),
+			must.Get(url.Parse(srv.URL)),
+			do.WithMethod(http.MethodPost),
+			do.WithPath("/upload"),
+			do.WithStatusCheck(),
+			do.WithMultipart(do.MultipartFile{Field: "image", Filename: "image.bin", Reader: strings.NewReader("data")}),
+		)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "data", received)
+	})
+
+	t.Run("should not replay files which cannot be rewound", func(t *testing.T) {
+		var received string
+		srv := redirecting(t, &received)
+
+		err := do.Do(
+			context.TODO(),
+			must.Get(url.Parse(srv.URL)),
+			do.WithMethod(http.MethodPost),
+			do.WithPath("/upload"),
+			do.WithStatusCheck(),
+			do.WithMultipart(do.MultipartFile{
+				Field:    "image",
+				Filename: "image.bin",
+				Reader:   io.MultiReader(strings.NewReader("data")),
+			}),
+		)
+
+		assert.ErrorIs(t, err, do.ErrBodyNotReplayable)
+		assert.Empty(t, received)
+	})
+}
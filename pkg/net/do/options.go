@@ -3,7 +3,7 @@ package do
 import (
 	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -11,23 +11,55 @@ import (
 	"time"
 
 	"go.uber.org/zap"
+
+	"github.com/merlindorin/go-shared/pkg/net/do/retry"
 )
 
 // Params holds configuration for an HTTP request.
 type Params struct {
 	Client HTTPClientDoer
 
+	// ClientDecorators wrap Client once every option is applied, in order: the
+	// last decorator is the outermost one.
+	ClientDecorators []ClientDecorator
+
 	Method string
 	Path   string
 	Body   io.Reader
 
-	PreRequestHandlers  map[string]PreRequestHandlerFunc
-	PostRequestHandlers map[string]PostRequestHandlerFunc
-	ErrorHandlers       map[string]ErrorHandlerFunc
+	// PathTemplate is the format of the path given to WithPath, used as a
+	// low-cardinality route label by the metrics of NewMetrics.
+	PathTemplate string
+
+	PreRequestHandlers  *Handlers[PreRequestHandlerFunc]
+	PostRequestHandlers *Handlers[PostRequestHandlerFunc]
+	ErrorHandlers       *Handlers[ErrorHandlerFunc]
 
 	Logger *zap.Logger
 
+	// Retry is the retry policy; the request is sent only once when nil.
+	Retry *retry.Policy
+
+	// ErrorDecoder decodes the body of error responses for WithStatusCheck.
+	ErrorDecoder ErrorDecoder
+
+	// Codec encodes bodies for WithMarshalBody, and decodes them for WithUnmarshalBody
+	// when Codecs has no codec for the response Content-Type.
+	Codec Codec
+	// Codecs selects the codec of WithUnmarshalBody from the response Content-Type.
+	Codecs *Codecs
+
+	// UploadProgress reports the progress of the body written by WithMultipart.
+	UploadProgress ProgressFunc
+
+	// HandlerObservers are notified of every handler run by Do.
+	HandlerObservers []HandlerObserver
+
 	now func() time.Time
+
+	// newRequest builds the request again, running the pre-request handlers, for
+	// the reconnections of WithSSE. It is set by Do.
+	newRequest func(ctx context.Context) (*http.Request, error)
 }
 
 // HTTPClientDoer is an interface for executing HTTP requests.
@@ -35,12 +67,15 @@ type HTTPClientDoer interface {
 	Do(req *http.Request) (*http.Response, error)
 }
 
-// NewParams creates a new Params with initialized handler maps.
+// ClientDecorator wraps the HTTP client of a request, such as a rate limiter or a cache.
+type ClientDecorator func(next HTTPClientDoer) HTTPClientDoer
+
+// NewParams creates a new Params with initialized handler chains.
 func NewParams() *Params {
 	return &Params{
-		PreRequestHandlers:  map[string]PreRequestHandlerFunc{},
-		PostRequestHandlers: map[string]PostRequestHandlerFunc{},
-		ErrorHandlers:       map[string]ErrorHandlerFunc{},
+		PreRequestHandlers:  NewHandlers[PreRequestHandlerFunc](),
+		PostRequestHandlers: NewHandlers[PostRequestHandlerFunc](),
+		ErrorHandlers:       NewHandlers[ErrorHandlerFunc](),
 	}
 }
 
@@ -76,7 +111,8 @@ func (p Option) Apply(params *Params) {
 	p(params)
 }
 
-// WithLogger sets the logger.
+// WithLogger sets the logger. The logs of Do carry the trace_id, span_id and
+// trace_flags of the OpenTelemetry span of the request context.
 func WithLogger(logger *zap.Logger) Option {
 	return func(params *Params) {
 		params.Logger = logger
@@ -90,13 +126,56 @@ func WithNow(fn func() time.Time) Option {
 	}
 }
 
-// WithClient sets the HTTP client.
+// WithClient sets the HTTP client. The client decorators wrap it whatever the
+// order of the options.
 func WithClient(cl HTTPClientDoer) Option {
 	return func(params *Params) {
 		params.Client = cl
 	}
 }
 
+// WithClientDecorator wraps the HTTP client with decorator. Decorators are applied
+// by Do once every option is, so that a later WithClient does not drop them, and
+// the decorators added last wrap the ones added first.
+func WithClientDecorator(decorator ClientDecorator) Option {
+	return func(params *Params) {
+		params.ClientDecorators = append(params.ClientDecorators, decorator)
+	}
+}
+
+// decorateClient wraps the client with the client decorators.
+func (p *Params) decorateClient() {
+	for _, decorate := range p.ClientDecorators {
+		p.Client = decorate(p.Client)
+	}
+}
+
+// WithRetry retries the request according to the given policy. The request is
+// rebuilt and the pre-request handlers are run again for every attempt, so the
+// body is replayed between attempts.
+func WithRetry(policy *retry.Policy) Option {
+	return func(params *Params) {
+		params.Retry = policy
+	}
+}
+
+// WithCodec sets the default Codec, JSON by default. It encodes the body of
+// WithMarshalBody and decodes the body of WithUnmarshalBody when the response
+// Content-Type has no registered codec.
+func WithCodec(codec Codec) Option {
+	return func(params *Params) {
+		params.Codec = codec
+	}
+}
+
+// WithCodecs sets the registry selecting the codec of WithUnmarshalBody from the
+// response Content-Type, DefaultCodecs by default.
+func WithCodecs(codecs *Codecs) Option {
+	return func(params *Params) {
+		params.Codecs = codecs
+	}
+}
+
 // WithMethod sets the HTTP method (GET, POST, etc.).
 func WithMethod(method string) Option {
 	return func(params *Params) {
@@ -136,7 +215,7 @@ func WithExtraHeaderf(key, format string, a ...any) Option {
 // WithHeader sets multiple HTTP headers from an http.Header map.
 func WithHeader(header http.Header) Option {
 	return WithPreRequestHandler(
-		"http_request_set_header",
+		HeaderHandler,
 		func(_ context.Context, req *http.Request) error {
 			for key, strings := range header {
 				for _, str := range strings {
@@ -151,11 +230,12 @@ func WithHeader(header http.Header) Option {
 // WithContentLength sets the Content-Length header.
 func WithContentLength(requestContent []byte) Option {
 	return WithPreRequestHandler(
-		"http_request_content_length",
+		ContentLengthHandler,
 		func(_ context.Context, req *http.Request) error {
 			req.ContentLength = int64(len(requestContent))
 			return nil
 		},
+		Priority(PriorityBody),
 	)
 }
 
@@ -163,6 +243,7 @@ func WithContentLength(requestContent []byte) Option {
 func WithPath(path string, a ...any) Option {
 	return func(params *Params) {
 		params.Path = fmt.Sprintf(path, a...)
+		params.PathTemplate = path
 	}
 }
 
@@ -173,34 +254,44 @@ func WithBody(b io.Reader) Option {
 	}
 }
 
-// WithMarshalBody marshals the value to JSON and sets it as the request body.
+// WithMarshalBody marshals the value with the Codec, sets it as the request body
+// and sets the Content-Type header to the media type of the Codec.
 func WithMarshalBody(v any) Option {
-	return WithPreRequestHandler(
-		"http_request_body_json_unmarshal",
-		func(_ context.Context, req *http.Request) error {
-			b, err := json.Marshal(v)
-			if err != nil {
-				return err
-			}
+	return func(params *Params) {
+		WithPreRequestHandler(
+			MarshalBodyHandler,
+			func(_ context.Context, req *http.Request) error {
+				b, err := params.Codec.Marshal(v)
+				if err != nil {
+					return err
+				}
 
-			req.Body = io.NopCloser(bytes.NewReader(b))
+				req.Body = io.NopCloser(bytes.NewReader(b))
+				req.ContentLength = int64(len(b))
+				req.GetBody = func() (io.ReadCloser, error) {
+					return io.NopCloser(bytes.NewReader(b)), nil
+				}
+				req.Header.Set("Content-Type", params.Codec.ContentType())
 
-			return nil
-		},
-	)
+				return nil
+			},
+			Priority(PriorityBody),
+		).Apply(params)
+	}
 }
 
-// WithPreRequestHandler registers a named pre-request handler.
-func WithPreRequestHandler(name string, f PreRequestHandlerFunc) Option {
+// WithPreRequestHandler registers a named pre-request handler, replacing any
+// handler registered under the same name.
+func WithPreRequestHandler(name string, f PreRequestHandlerFunc, opts ...HandlerOption) Option {
 	return func(params *Params) {
-		params.PreRequestHandlers[name] = f
+		params.PreRequestHandlers.Set(name, f, opts...)
 	}
 }
 
 // WithJSONRequest sets Content-Type to "application/json".
 func WithJSONRequest() Option {
 	return WithPreRequestHandler(
-		"http_request_header_json",
+		JSONRequestHandler,
 		func(_ context.Context, req *http.Request) error {
 			req.Header.Set("Content-Type", "application/json")
 			return nil
@@ -208,41 +299,146 @@ func WithJSONRequest() Option {
 	)
 }
 
-// WithPostRequestHandler registers a named post-request handler.
-func WithPostRequestHandler(name string, f PostRequestHandlerFunc) Option {
+// WithPostRequestHandler registers a named post-request handler, replacing any
+// handler registered under the same name.
+func WithPostRequestHandler(name string, f PostRequestHandlerFunc, opts ...HandlerOption) Option {
 	return func(params *Params) {
-		params.PostRequestHandlers[name] = f
+		params.PostRequestHandlers.Set(name, f, opts...)
 	}
 }
 
-// WithErrorHandler registers a named error handler.
-func WithErrorHandler(name string, f ErrorHandlerFunc) Option {
+// WithErrorHandler registers a named error handler, replacing any handler
+// registered under the same name.
+func WithErrorHandler(name string, f ErrorHandlerFunc, opts ...HandlerOption) Option {
 	return func(params *Params) {
-		params.ErrorHandlers[name] = f
+		params.ErrorHandlers.Set(name, f, opts...)
 	}
 }
 
-// WithUnmarshalBody unmarshals the JSON response body into the provided value.
+// WithUnmarshalBody unmarshals the response body into the provided value with the
+// codec registered for the response Content-Type, or the Codec when there is none
+// or when it cannot decode into v, such as JSON served as text/plain into a struct.
+// The Accept header defaults to the media type of the Codec. Empty bodies are
+// left undecoded.
 func WithUnmarshalBody(v any) Option {
-	return WithPostRequestHandler(
-		"http_response_body_json_unmarshal",
-		func(_ context.Context, _ *http.Request, res *http.Response) error {
-			if v == nil || (reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil()) {
+	return func(params *Params) {
+		WithPreRequestHandler(
+			AcceptHandler,
+			func(_ context.Context, req *http.Request) error {
+				if req.Header.Get("Accept") == "" {
+					req.Header.Set("Accept", params.Codec.ContentType())
+				}
 				return nil
-			}
+			},
+			Priority(PriorityBody),
+		).Apply(params)
+
+		WithPostRequestHandler(
+			UnmarshalBodyHandler,
+			func(_ context.Context, _ *http.Request, res *http.Response) error {
+				if v == nil || (reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil()) {
+					return nil
+				}
+
+				if res.Body == nil {
+					return nil
+				}
+
+				body, err := io.ReadAll(res.Body)
+				if err != nil {
+					return err
+				}
+
+				res.Body = io.NopCloser(bytes.NewBuffer(body))
+
+				if len(body) == 0 {
+					return nil
+				}
+
+				err = responseCodec(params, res).Unmarshal(body, v)
+				if errors.Is(err, ErrUnsupportedValue) {
+					return params.Codec.Unmarshal(body, v)
+				}
 
-			body, err := io.ReadAll(res.Body)
-			if err != nil {
 				return err
-			}
+			},
+			Priority(PriorityBody),
+		).Apply(params)
+	}
+}
 
-			err = json.Unmarshal(body, v)
+// WithErrorDecoder sets the ErrorDecoder used by WithStatusCheck. It defaults to
+// DecodeProblemDetails, a custom decoder may fall back to it for problem+json bodies.
+func WithErrorDecoder(decoder ErrorDecoder) Option {
+	return func(params *Params) {
+		params.ErrorDecoder = decoder
+	}
+}
+
+// WithStatusCheck turns responses with a 4xx or 5xx status code into an *HTTPError,
+// wrapping the error decoded from the body by the ErrorDecoder. The check runs
+// before the other post-request handlers, so that an error payload is never
+// decoded as a successful response. The body read for the error is put back for
+// the error handlers.
+func WithStatusCheck() Option {
+	return func(params *Params) {
+		WithPostRequestHandler(StatusCheckHandler, statusCheck(params), Priority(PriorityStatusCheck)).Apply(params)
+	}
+}
+
+// statusCheck returns the post-request handler of WithStatusCheck.
+func statusCheck(params *Params) PostRequestHandlerFunc {
+	return func(_ context.Context, req *http.Request, res *http.Response) error {
+		if res.StatusCode < http.StatusBadRequest {
+			return nil
+		}
+
+		httpErr := &HTTPError{
+			StatusCode: res.StatusCode,
+			Header:     res.Header,
+			Method:     req.Method,
+			URL:        req.URL.String(),
+		}
+
+		if res.Body != nil {
+			body, err := io.ReadAll(io.LimitReader(res.Body, MaxErrorBodySize+1))
 			if err != nil {
-				return err
+				return errors.Join(httpErr, err)
 			}
 
-			res.Body = io.NopCloser(bytes.NewBuffer(body))
-			return nil
-		},
-	)
+			httpErr.Body = body[:min(len(body), MaxErrorBodySize)]
+			httpErr.Truncated = len(body) > MaxErrorBodySize
+			res.Body = readCloser{io.MultiReader(bytes.NewReader(body), res.Body), res.Body}
+		}
+
+		if params.ErrorDecoder != nil {
+			httpErr.Err = params.ErrorDecoder(res, httpErr.Body)
+		}
+
+		return httpErr
+	}
+}
+
+// WithoutStatusCheck removes the status check registered with WithStatusCheck.
+func WithoutStatusCheck() Option {
+	return func(params *Params) {
+		params.PostRequestHandlers.Delete(StatusCheckHandler)
+	}
+}
+
+// readCloser combines a reader with the closer of the original body.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// responseCodec returns the codec registered for the response Content-Type, or the default Codec.
+func responseCodec(params *Params, res *http.Response) Codec {
+	if params.Codecs != nil && res.Header != nil {
+		if codec, ok := params.Codecs.Lookup(res.Header.Get("Content-Type")); ok {
+			return codec
+		}
+	}
+
+	return params.Codec
 }
@@ -0,0 +1,136 @@
+package do
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// ProblemJSONContentType is the media type of RFC 9457 problem details.
+const ProblemJSONContentType = "application/problem+json"
+
+// ErrorDecoder decodes the body of an error response into an error. It returns
+// nil when it does not recognise the body. The body holds at most
+// MaxErrorBodySize bytes, a longer body is truncated and HTTPError.Truncated is set.
+type ErrorDecoder func(res *http.Response, body []byte) error
+
+// ProblemDetails is an RFC 9457 problem details object. Members that are not
+// defined by the RFC are kept in Extensions.
+type ProblemDetails struct {
+	Type     string
+	Title    string
+	Status   int
+	Detail   string
+	Instance string
+
+	Extensions map[string]any
+}
+
+// Error returns the title and the detail of the problem.
+func (p *ProblemDetails) Error() string {
+	title := p.Title
+	if title == "" {
+		title = p.Type
+	}
+	if title == "" {
+		title = http.StatusText(p.Status)
+	}
+
+	if p.Detail == "" {
+		return title
+	}
+
+	return title + ": " + p.Detail
+}
+
+// MarshalJSON encodes the problem with its extension members at the top level.
+func (p *ProblemDetails) MarshalJSON() ([]byte, error) {
+	m := make(map[string]any, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		m[k] = v
+	}
+
+	setIfNotEmpty(m, "type", p.Type)
+	setIfNotEmpty(m, "title", p.Title)
+	setIfNotEmpty(m, "detail", p.Detail)
+	setIfNotEmpty(m, "instance", p.Instance)
+	if p.Status != 0 {
+		m["status"] = p.Status
+	}
+
+	return json.Marshal(m)
+}
+
+// UnmarshalJSON decodes the problem, members that are not defined by the RFC
+// go to Extensions. Members with an unexpected type are ignored as required by the RFC.
+func (p *ProblemDetails) UnmarshalJSON(b []byte) error {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(b, &members); err != nil {
+		return err
+	}
+
+	*p = ProblemDetails{}
+
+	for k, raw := range members {
+		switch k {
+		case "type":
+			unmarshalMember(raw, &p.Type)
+		case "title":
+			unmarshalMember(raw, &p.Title)
+		case "status":
+			unmarshalMember(raw, &p.Status)
+		case "detail":
+			unmarshalMember(raw, &p.Detail)
+		case "instance":
+			unmarshalMember(raw, &p.Instance)
+		default:
+			var v any
+			if err := json.Unmarshal(raw, &v); err != nil {
+				return err
+			}
+
+			if p.Extensions == nil {
+				p.Extensions = map[string]any{}
+			}
+			p.Extensions[k] = v
+		}
+	}
+
+	return nil
+}
+
+// DecodeProblemDetails is the default ErrorDecoder, it decodes
+// application/problem+json bodies into a *ProblemDetails. Problems larger than
+// MaxErrorBodySize are truncated and left undecoded: the HTTPError has no Err and
+// its Truncated field is set.
+func DecodeProblemDetails(res *http.Response, body []byte) error {
+	mediaType, _, err := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if err != nil || !strings.EqualFold(mediaType, ProblemJSONContentType) {
+		return nil
+	}
+
+	problem := &ProblemDetails{}
+	if err = json.Unmarshal(body, problem); err != nil {
+		return nil //nolint:nilerr // a malformed problem is reported through the HTTPError only
+	}
+
+	if problem.Status == 0 {
+		problem.Status = res.StatusCode
+	}
+
+	return problem
+}
+
+// setIfNotEmpty sets the key of m when v is not empty.
+func setIfNotEmpty(m map[string]any, k, v string) {
+	if v != "" {
+		m[k] = v
+	}
+}
+
+// unmarshalMember decodes a problem member, leaving v untouched when the member
+// has an unexpected type.
+func unmarshalMember(raw json.RawMessage, v any) {
+	_ = json.Unmarshal(raw, v)
+}
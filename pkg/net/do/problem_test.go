@@ -0,0 +1,113 @@
+package do_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/merlindorin/go-shared/pkg/net/do"
+)
+
+func TestProblemDetails(t *testing.T) {
+	t.Run("should decode problem+json error responses", func(t *testing.T) {
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).Return(&http.Response{
+			StatusCode: http.StatusForbidden,
+			Header:     http.Header{"Content-Type": []string{"application/problem+json; charset=utf-8"}},
+			Body: io.NopCloser(strings.NewReader(`{
+				"type": "https://example.com/probs/out-of-credit",
+				"title": "You do not have enough credit.",
+				"detail": "Your current balance is 30, but that costs 50.",
+				"instance": "/account/12345/msgs/abc",
+				"balance": 30
+			}`)),
+		}, nil).Once()
+
+		err := do.Do(context.TODO(), &url.URL{}, do.WithClient(mockClient), do.WithStatusCheck())
+
+		var problem *do.ProblemDetails
+		assert.ErrorAs(t, err, &problem)
+		assert.ErrorIs(t, err, do.ErrForbidden)
+		assert.Equal(t, "https://example.com/probs/out-of-credit", problem.Type)
+		assert.Equal(t, http.StatusForbidden, problem.Status)
+		assert.Equal(t, "/account/12345/msgs/abc", problem.Instance)
+		assert.Equal(t, map[string]any{"balance": float64(30)}, problem.Extensions)
+		assert.ErrorContains(t, err, "You do not have enough credit.: Your current balance is 30, but that costs 50.")
+	})
+
+	t.Run("should not decode other content types", func(t *testing.T) {
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).Return(&http.Response{
+			StatusCode: http.StatusBadRequest,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"title": "bad"}`)),
+		}, nil).Once()
+
+		err := do.Do(context.TODO(), &url.URL{}, do.WithClient(mockClient), do.WithStatusCheck())
+
+		var problem *do.ProblemDetails
+		assert.False(t, errors.As(err, &problem))
+		assert.ErrorIs(t, err, do.ErrBadRequest)
+	})
+
+	t.Run("should use a custom error decoder", func(t *testing.T) {
+		wantErr := errors.New("vendor error")
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).Return(&http.Response{
+			StatusCode: http.StatusInternalServerError,
+			Body:       io.NopCloser(strings.NewReader(`{"error": {"code": 42}}`)),
+		}, nil).Once()
+
+		err := do.Do(
+			context.TODO(),
+			&url.URL{},
+			do.WithClient(mockClient),
+			do.WithStatusCheck(),
+			do.WithErrorDecoder(func(_ *http.Response, body []byte) error {
+				assert.JSONEq(t, `{"error": {"code": 42}}`, string(body))
+				return wantErr
+			}),
+		)
+
+		assert.ErrorIs(t, err, wantErr)
+		assert.ErrorIs(t, err, do.ErrServerError)
+	})
+
+	t.Run("should report problems larger than MaxErrorBodySize as truncated", func(t *testing.T) {
+		detail := strings.Repeat("x", do.MaxErrorBodySize)
+
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).Return(&http.Response{
+			StatusCode: http.StatusBadRequest,
+			Header:     http.Header{"Content-Type": []string{do.ProblemJSONContentType}},
+			Body:       io.NopCloser(strings.NewReader(`{"title": "Bad", "detail": "` + detail + `"}`)),
+		}, nil).Once()
+
+		err := do.Do(context.TODO(), &url.URL{}, do.WithClient(mockClient), do.WithStatusCheck())
+
+		var httpErr *do.HTTPError
+		assert.ErrorAs(t, err, &httpErr)
+		assert.True(t, httpErr.Truncated)
+		assert.Len(t, httpErr.Body, do.MaxErrorBodySize)
+		assert.NoError(t, httpErr.Err)
+	})
+
+	t.Run("should encode extensions at the top level", func(t *testing.T) {
+		b, err := json.Marshal(&do.ProblemDetails{
+			Title:      "Not Found",
+			Status:     http.StatusNotFound,
+			Extensions: map[string]any{"id": "42"},
+		})
+
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"title": "Not Found", "status": 404, "id": "42"}`, string(b))
+	})
+}
@@ -0,0 +1,303 @@
+package do
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/semaphore"
+	"golang.org/x/time/rate"
+
+	"github.com/merlindorin/go-shared/pkg/net/do/retry"
+)
+
+// defaultBucketIdleTimeout is the default time after which an unused bucket is dropped.
+const defaultBucketIdleTimeout = 10 * time.Minute
+
+// epochThreshold separates reset values given as Unix times from values given in seconds.
+const epochThreshold = 1_000_000_000
+
+// RateLimiter delays requests to comply with a rate limit. Implementations must
+// be safe for concurrent use.
+type RateLimiter interface {
+	// Wait blocks until the request can be sent or ctx is done.
+	Wait(ctx context.Context, req *http.Request) error
+	// Observe adapts the limiter to the rate limit advertised by the response.
+	Observe(req *http.Request, res *http.Response)
+}
+
+// TokenBucket is a token bucket RateLimiter, optionally keeping a bucket per host.
+// It adapts to the RateLimit, RateLimit-* and X-RateLimit-* response headers: the
+// rate is lowered to the remaining quota over the reset delay, and requests are
+// held until the reset once the quota is exhausted or on 429 Too Many Requests.
+type TokenBucket struct {
+	limit       rate.Limit
+	burst       int
+	perHost     bool
+	idleTimeout time.Duration
+	now         func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	nextSweep time.Time
+}
+
+// bucket is the state of a TokenBucket for a host.
+type bucket struct {
+	limiter      *rate.Limiter
+	blockedUntil time.Time
+	lastUsed     time.Time
+}
+
+// TokenBucketOption represents a configuration setting that can be applied to a TokenBucket.
+type TokenBucketOption func(t *TokenBucket)
+
+// apply sets the given TokenBucketOption to the TokenBucket.
+func (o TokenBucketOption) apply(t *TokenBucket) {
+	o(t)
+}
+
+// PerHost keeps a separate bucket for every host.
+func PerHost() TokenBucketOption {
+	return func(t *TokenBucket) {
+		t.perHost = true
+	}
+}
+
+// BucketIdleTimeout drops the bucket of a host once it has not been used for d,
+// 10 minutes by default, so that the buckets of PerHost do not grow without bound.
+func BucketIdleTimeout(d time.Duration) TokenBucketOption {
+	return func(t *TokenBucket) {
+		t.idleTimeout = d
+	}
+}
+
+// NewTokenBucket creates a TokenBucket allowing perSecond requests per second with bursts of burst requests.
+func NewTokenBucket(perSecond float64, burst int, opts ...TokenBucketOption) *TokenBucket {
+	t := &TokenBucket{
+		limit:       rate.Limit(perSecond),
+		burst:       burst,
+		idleTimeout: defaultBucketIdleTimeout,
+		now:         time.Now,
+		buckets:     map[string]*bucket{},
+	}
+
+	for _, opt := range opts {
+		opt.apply(t)
+	}
+
+	return t
+}
+
+// Wait blocks until the bucket of the request allows it or ctx is done.
+func (t *TokenBucket) Wait(ctx context.Context, req *http.Request) error {
+	b := t.bucket(req)
+
+	t.mu.Lock()
+	blockedFor := b.blockedUntil.Sub(t.now())
+	t.mu.Unlock()
+
+	if blockedFor > 0 {
+		if err := sleep(ctx, blockedFor); err != nil {
+			return err
+		}
+	}
+
+	return b.limiter.Wait(ctx)
+}
+
+// Observe adapts the bucket of the request to the rate limit headers of the response.
+func (t *TokenBucket) Observe(req *http.Request, res *http.Response) {
+	now := t.now()
+	remaining, reset, ok := parseRateLimit(res.Header, now)
+
+	if res.StatusCode == http.StatusTooManyRequests {
+		if d, found := retry.ParseRetryAfter(res.Header.Get("Retry-After"), now); found {
+			remaining, reset, ok = 0, d, true
+		}
+	}
+
+	if !ok {
+		return
+	}
+
+	b := t.bucket(req)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if remaining <= 0 {
+		b.blockedUntil = now.Add(reset)
+		return
+	}
+
+	limit := t.limit
+	if reset > 0 {
+		limit = min(t.limit, rate.Limit(float64(remaining)/reset.Seconds()))
+	}
+	b.limiter.SetLimit(limit)
+}
+
+// bucket returns the bucket of the request, creating it if needed.
+func (t *TokenBucket) bucket(req *http.Request) *bucket {
+	var key string
+	if t.perHost {
+		key = req.URL.Host
+	}
+
+	now := t.now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.sweep(now)
+
+	b, ok := t.buckets[key]
+	if !ok {
+		b = &bucket{limiter: rate.NewLimiter(t.limit, t.burst)}
+		t.buckets[key] = b
+	}
+	b.lastUsed = now
+
+	return b
+}
+
+// Len returns the number of buckets.
+func (t *TokenBucket) Len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return len(t.buckets)
+}
+
+// sweep drops the buckets unused for the idle timeout and no longer blocked, at
+// most once per idle timeout. The lock must be held.
+func (t *TokenBucket) sweep(now time.Time) {
+	if now.Before(t.nextSweep) {
+		return
+	}
+	t.nextSweep = now.Add(t.idleTimeout)
+
+	for key, b := range t.buckets {
+		if now.Sub(b.lastUsed) >= t.idleTimeout && now.After(b.blockedUntil) {
+			delete(t.buckets, key)
+		}
+	}
+}
+
+// parseRateLimit returns the remaining quota and the delay until its reset from
+// the RateLimit, RateLimit-* or X-RateLimit-* headers.
+func parseRateLimit(h http.Header, now time.Time) (int, time.Duration, bool) {
+	if v := h.Get("RateLimit"); v != "" {
+		remaining, reset := -1, -1
+
+		for _, field := range strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ';' }) {
+			key, value, _ := strings.Cut(strings.TrimSpace(field), "=")
+			n, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil {
+				continue
+			}
+
+			switch key {
+			case "remaining", "r":
+				remaining = n
+			case "reset", "t":
+				reset = n
+			}
+		}
+
+		if remaining >= 0 && reset >= 0 {
+			return remaining, time.Duration(reset) * time.Second, true
+		}
+	}
+
+	for _, prefix := range []string{"RateLimit-", "X-RateLimit-"} {
+		remaining, err := strconv.Atoi(h.Get(prefix + "Remaining"))
+		if err != nil {
+			continue
+		}
+
+		reset, err := strconv.ParseInt(h.Get(prefix+"Reset"), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		d := time.Duration(reset) * time.Second
+		if reset > epochThreshold {
+			d = time.Unix(reset, 0).Sub(now)
+		}
+
+		return remaining, max(d, 0), true
+	}
+
+	return 0, 0, false
+}
+
+// WithRateLimiter delays every request until the limiter allows it, then lets the
+// limiter observe the response. The limiter is shared by every request using the
+// option, including the requests of rest clients and their children.
+func WithRateLimiter(limiter RateLimiter) Option {
+	return WithClientDecorator(func(next HTTPClientDoer) HTTPClientDoer {
+		return &rateLimitedClient{next: next, limiter: limiter}
+	})
+}
+
+// rateLimitedClient is an HTTPClientDoer applying a RateLimiter.
+type rateLimitedClient struct {
+	next    HTTPClientDoer
+	limiter RateLimiter
+}
+
+// Do waits for the limiter then sends the request.
+func (c *rateLimitedClient) Do(req *http.Request) (*http.Response, error) {
+	if err := c.limiter.Wait(req.Context(), req); err != nil {
+		return nil, err
+	}
+
+	res, err := c.next.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	c.limiter.Observe(req, res)
+
+	return res, nil
+}
+
+// WithMaxInFlight limits the number of requests in flight to n, a request being
+// in flight until its response body is read to the end or closed. Requests wait for a slot until
+// their context is done. The limit is shared by every request using the option,
+// including the requests of rest clients and their children.
+func WithMaxInFlight(n int64) Option {
+	sem := semaphore.NewWeighted(max(n, 1))
+
+	return WithClientDecorator(func(next HTTPClientDoer) HTTPClientDoer {
+		return &inFlightClient{next: next, sem: sem}
+	})
+}
+
+// inFlightClient is an HTTPClientDoer limiting the number of requests in flight.
+type inFlightClient struct {
+	next HTTPClientDoer
+	sem  *semaphore.Weighted
+}
+
+// Do acquires a slot, sends the request and releases the slot once the body is read or closed.
+func (c *inFlightClient) Do(req *http.Request) (*http.Response, error) {
+	if err := c.sem.Acquire(req.Context(), 1); err != nil {
+		return nil, err
+	}
+
+	res, err := c.next.Do(req)
+	if err != nil || res.Body == nil {
+		c.sem.Release(1)
+		return res, err
+	}
+
+	res.Body = NewCountingBody(res.Body, func(int64) { c.sem.Release(1) })
+
+	return res, nil
+}
@@ -0,0 +1,172 @@
+package do_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/merlindorin/go-shared/pkg/must"
+	"github.com/merlindorin/go-shared/pkg/net/do"
+)
+
+func TestWithRateLimiter(t *testing.T) {
+	u := must.Get(url.Parse("http://localhost"))
+
+	t.Run("should block until the context is done once the quota is exhausted", func(t *testing.T) {
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Ratelimit-Remaining": {"0"}, "Ratelimit-Reset": {"60"}},
+			Body:       io.NopCloser(strings.NewReader("")),
+		}, nil).Once()
+
+		limiter := do.NewTokenBucket(100, 10)
+
+		assert.NoError(t, do.Do(t.Context(), u, do.WithClient(mockClient), do.WithRateLimiter(limiter)))
+
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+		defer cancel()
+
+		assert.ErrorIs(t, do.Do(ctx, u, do.WithClient(mockClient), do.WithRateLimiter(limiter)), context.DeadlineExceeded)
+	})
+
+	t.Run("should keep a bucket per host", func(t *testing.T) {
+		other := must.Get(url.Parse("http://example.com"))
+
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).Return(&http.Response{
+			StatusCode: http.StatusTooManyRequests,
+			Header:     http.Header{"Retry-After": {"60"}},
+			Body:       io.NopCloser(strings.NewReader("")),
+		}, nil).Twice()
+
+		limiter := do.NewTokenBucket(100, 10, do.PerHost())
+
+		assert.NoError(t, do.Do(t.Context(), u, do.WithClient(mockClient), do.WithRateLimiter(limiter)))
+		assert.NoError(t, do.Do(t.Context(), other, do.WithClient(mockClient), do.WithRateLimiter(limiter)))
+	})
+
+	t.Run("should adapt to the RateLimit header", func(t *testing.T) {
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Ratelimit": {`"default";r=0;t=60`}},
+			Body:       io.NopCloser(strings.NewReader("")),
+		}, nil).Once()
+
+		limiter := do.NewTokenBucket(100, 10)
+
+		assert.NoError(t, do.Do(t.Context(), u, do.WithClient(mockClient), do.WithRateLimiter(limiter)))
+
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+		defer cancel()
+
+		assert.ErrorIs(t, do.Do(ctx, u, do.WithClient(mockClient), do.WithRateLimiter(limiter)), context.DeadlineExceeded)
+	})
+
+	t.Run("should keep limiting when the client is set afterwards", func(t *testing.T) {
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader("")),
+		}, nil).Once()
+
+		limiter := do.NewTokenBucket(1, 1)
+
+		assert.NoError(t, do.Do(t.Context(), u, do.WithRateLimiter(limiter), do.WithClient(mockClient)))
+
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+		defer cancel()
+
+		assert.ErrorContains(t, do.Do(ctx, u, do.WithRateLimiter(limiter), do.WithClient(mockClient)), "deadline")
+	})
+
+	t.Run("should drop the buckets of idle hosts", func(t *testing.T) {
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).RunAndReturn(func(*http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(""))}, nil
+		}).Times(3)
+
+		limiter := do.NewTokenBucket(100, 10, do.PerHost(), do.BucketIdleTimeout(10*time.Millisecond))
+
+		for _, host := range []string{"http://a.example.com", "http://b.example.com"} {
+			hostURL := must.Get(url.Parse(host))
+			assert.NoError(t, do.Do(t.Context(), hostURL, do.WithClient(mockClient), do.WithRateLimiter(limiter)))
+		}
+		assert.Equal(t, 2, limiter.Len())
+
+		time.Sleep(20 * time.Millisecond)
+
+		assert.NoError(t, do.Do(t.Context(), u, do.WithClient(mockClient), do.WithRateLimiter(limiter)))
+		assert.Equal(t, 1, limiter.Len())
+	})
+}
+
+func TestWithMaxInFlight(t *testing.T) {
+	u := must.Get(url.Parse("http://localhost"))
+
+	t.Run("should wait for a slot until the body is closed", func(t *testing.T) {
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader("")),
+		}, nil).Twice()
+
+		limit := do.WithMaxInFlight(1)
+
+		assert.NoError(t, do.Do(t.Context(), u, do.WithClient(mockClient), limit))
+		assert.NoError(t, do.Do(t.Context(), u, do.WithClient(mockClient), limit))
+	})
+
+	t.Run("should free the slot of decoded responses", func(t *testing.T) {
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).RunAndReturn(func(*http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{"name":"item"}`))}, nil
+		}).Times(3)
+
+		limit := do.WithMaxInFlight(1)
+
+		for range 3 {
+			ctx, cancel := context.WithTimeout(t.Context(), time.Second)
+			var got item
+
+			assert.NoError(t, do.Do(ctx, u, do.WithClient(mockClient), limit, do.WithUnmarshalBody(&got)))
+			assert.Equal(t, item{Name: "item"}, got)
+			cancel()
+		}
+	})
+
+	t.Run("should block until the context is done when no slot is free", func(t *testing.T) {
+		started, release, done := make(chan struct{}), make(chan struct{}), make(chan struct{})
+
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).RunAndReturn(func(*http.Request) (*http.Response, error) {
+			close(started)
+			<-release
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(""))}, nil
+		}).Once()
+
+		limit := do.WithMaxInFlight(1)
+
+		go func() {
+			defer close(done)
+			_ = do.Do(t.Context(), u, do.WithClient(mockClient), limit)
+		}()
+		<-started
+
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+		defer cancel()
+
+		assert.ErrorIs(t, do.Do(ctx, u, do.WithClient(mockClient), limit), context.DeadlineExceeded)
+
+		close(release)
+		<-done
+	})
+}
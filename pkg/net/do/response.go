@@ -0,0 +1,68 @@
+package do
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Response holds the metadata of a response.
+type Response struct {
+	// StatusCode is the response status code.
+	StatusCode int
+	// Status is the response status line, such as "200 OK".
+	Status string
+	// Header holds the response headers.
+	Header http.Header
+	// Trailer holds the response trailers. They are available once the body is
+	// fully read, by a response handler such as WithUnmarshalBody.
+	Trailer http.Header
+	// ContentLength is the length of the body, -1 when unknown.
+	ContentLength int64
+	// URL is the URL of the request which produced the response, after redirects.
+	URL *url.URL
+	// Proto is the protocol of the response, such as "HTTP/2.0".
+	Proto string
+
+	// Start is when the request was sent.
+	Start time.Time
+	// Received is when the response headers were received.
+	Received time.Time
+}
+
+// Duration returns the time between sending the request and receiving the response headers.
+func (r *Response) Duration() time.Duration {
+	return r.Received.Sub(r.Start)
+}
+
+// WithResponseInto fills into with the metadata of the response. It is filled
+// before the response status is checked, so it is also available when the
+// request fails with a *HTTPError. Several WithResponseInto options can be set
+// on the same request.
+func WithResponseInto(into *Response) Option {
+	name := fmt.Sprintf("%s_%p", ResponseIntoHandler, into)
+
+	return func(params *Params) {
+		WithPreRequestHandler(name, func(context.Context, *http.Request) error {
+			into.Start = params.now()
+			return nil
+		}, Priority(PrioritySigning)).Apply(params)
+
+		WithPostRequestHandler(name, func(_ context.Context, req *http.Request, res *http.Response) error {
+			into.Received = params.now()
+			into.StatusCode = res.StatusCode
+			into.Status = res.Status
+			into.Header = res.Header
+			into.Trailer = res.Trailer
+			into.ContentLength = res.ContentLength
+			into.Proto = res.Proto
+			into.URL = req.URL
+			if res.Request != nil {
+				into.URL = res.Request.URL
+			}
+			return nil
+		}, Priority(PriorityStatusCheck), Before(StatusCheckHandler)).Apply(params)
+	}
+}
@@ -0,0 +1,86 @@
+package do_test
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/merlindorin/go-shared/pkg/must"
+	"github.com/merlindorin/go-shared/pkg/net/do"
+)
+
+func TestWithResponseInto(t *testing.T) {
+	u := must.Get(url.Parse("http://localhost/items"))
+
+	t.Run("should capture the response metadata", func(t *testing.T) {
+		now := time.Now()
+		redirected := must.Get(url.Parse("http://localhost/v2/items"))
+
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).RunAndReturn(func(*http.Request) (*http.Response, error) {
+			now = now.Add(time.Second)
+			return &http.Response{
+				StatusCode:    http.StatusOK,
+				Status:        "200 OK",
+				Proto:         "HTTP/2.0",
+				Header:        http.Header{"Content-Type": {"application/json"}},
+				Trailer:       http.Header{"Checksum": {"abc"}},
+				ContentLength: 15,
+				Body:          io.NopCloser(strings.NewReader(`{"name":"item"}`)),
+				Request:       &http.Request{URL: redirected},
+			}, nil
+		}).Once()
+
+		var (
+			got  item
+			meta do.Response
+		)
+
+		err := do.Do(t.Context(), u,
+			do.WithClient(mockClient),
+			do.WithNow(func() time.Time { return now }),
+			do.WithResponseInto(&meta),
+			do.WithUnmarshalBody(&got),
+		)
+
+		assert.NoError(t, err)
+		assert.Equal(t, item{Name: "item"}, got)
+		assert.Equal(t, http.StatusOK, meta.StatusCode)
+		assert.Equal(t, "200 OK", meta.Status)
+		assert.Equal(t, "HTTP/2.0", meta.Proto)
+		assert.Equal(t, "application/json", meta.Header.Get("Content-Type"))
+		assert.Equal(t, "abc", meta.Trailer.Get("Checksum"))
+		assert.Equal(t, int64(15), meta.ContentLength)
+		assert.Equal(t, redirected, meta.URL)
+		assert.Equal(t, time.Second, meta.Duration())
+	})
+
+	t.Run("should capture the metadata of failed requests into several responses", func(t *testing.T) {
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).Return(&http.Response{
+			StatusCode: http.StatusNotFound,
+			Header:     http.Header{"X-Request-Id": {"42"}},
+			Body:       io.NopCloser(strings.NewReader("")),
+		}, nil).Once()
+
+		var first, second do.Response
+
+		err := do.Do(t.Context(), u,
+			do.WithClient(mockClient),
+			do.WithStatusCheck(),
+			do.WithResponseInto(&first),
+			do.WithResponseInto(&second),
+		)
+
+		assert.ErrorIs(t, err, do.ErrNotFound)
+		assert.Equal(t, http.StatusNotFound, first.StatusCode)
+		assert.Equal(t, "42", second.Header.Get("X-Request-Id"))
+		assert.Equal(t, u.String(), second.URL.String())
+	})
+}
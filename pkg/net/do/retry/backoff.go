@@ -0,0 +1,81 @@
+package retry
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// Backoff computes the delay to wait before a retry.
+type Backoff interface {
+	// Delay returns the delay before the given retry. attempt starts at 1 for
+	// the first retry and previous is the delay used for the preceding retry,
+	// zero for the first one.
+	Delay(attempt int, previous time.Duration) time.Duration
+}
+
+// BackoffFunc is an adapter to allow the use of ordinary functions as Backoff.
+type BackoffFunc func(attempt int, previous time.Duration) time.Duration
+
+// Delay calls f(attempt, previous).
+func (f BackoffFunc) Delay(attempt int, previous time.Duration) time.Duration {
+	return f(attempt, previous)
+}
+
+// Constant waits the same delay before every retry.
+func Constant(d time.Duration) Backoff {
+	return BackoffFunc(func(_ int, _ time.Duration) time.Duration {
+		return d
+	})
+}
+
+// Exponential doubles the delay on every retry, starting from base and capped at maxDelay.
+func Exponential(base, maxDelay time.Duration) Backoff {
+	return BackoffFunc(func(attempt int, _ time.Duration) time.Duration {
+		return exponential(base, maxDelay, attempt)
+	})
+}
+
+// ExponentialFullJitter picks a random delay between zero and the Exponential delay.
+// See https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/.
+func ExponentialFullJitter(base, maxDelay time.Duration) Backoff {
+	return BackoffFunc(func(attempt int, _ time.Duration) time.Duration {
+		return randBetween(0, exponential(base, maxDelay, attempt))
+	})
+}
+
+// DecorrelatedJitter picks a random delay between base and three times the previous
+// delay, capped at maxDelay.
+// See https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/.
+func DecorrelatedJitter(base, maxDelay time.Duration) Backoff {
+	return BackoffFunc(func(_ int, previous time.Duration) time.Duration {
+		upper := max(previous*3, base)
+		return min(randBetween(base, upper), maxDelay)
+	})
+}
+
+// exponential returns base * 2^(attempt-1), capped at maxDelay.
+func exponential(base, maxDelay time.Duration, attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	d := base
+	for i := 1; i < attempt; i++ {
+		if d >= maxDelay/2 {
+			return maxDelay
+		}
+		d *= 2
+	}
+
+	return min(d, maxDelay)
+}
+
+// randBetween returns a random duration in [low, high].
+func randBetween(low, high time.Duration) time.Duration {
+	if high <= low {
+		return low
+	}
+
+	//nolint:gosec // jitter does not need a cryptographically secure source
+	return low + rand.N(high-low+1)
+}
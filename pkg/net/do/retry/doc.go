@@ -0,0 +1,9 @@
+// Package retry provides the retry policy used by do.WithRetry. A Policy
+// decides whether a failed attempt should be retried, based on transport
+// errors and response status codes, and how long to wait before the next
+// attempt, honouring the Retry-After response header when present.
+//
+// Backoff strategies are pluggable through the Backoff interface. The package
+// ships constant, exponential, exponential with full jitter and decorrelated
+// jitter strategies.
+package retry
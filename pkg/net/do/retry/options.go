@@ -0,0 +1,50 @@
+package retry
+
+import "time"
+
+// Option represents a configuration setting that can be applied to a Policy.
+type Option func(p *Policy)
+
+// apply sets the given Option to the Policy.
+func (o Option) apply(p *Policy) {
+	o(p)
+}
+
+// WithMaxAttempts sets the maximum number of attempts, including the first one.
+func WithMaxAttempts(n int) Option {
+	return func(p *Policy) {
+		p.maxAttempts = max(n, 1)
+	}
+}
+
+// WithBackoff sets the Backoff used between attempts.
+func WithBackoff(b Backoff) Option {
+	return func(p *Policy) {
+		p.backoff = b
+	}
+}
+
+// WithStatusCodes replaces the response status codes that trigger a retry.
+func WithStatusCodes(codes ...int) Option {
+	return func(p *Policy) {
+		p.statusCodes = make(map[int]struct{}, len(codes))
+		for _, code := range codes {
+			p.statusCodes[code] = struct{}{}
+		}
+	}
+}
+
+// WithMaxRetryAfter caps the delay requested by the Retry-After header, so that
+// a server cannot hold a request for longer than d.
+func WithMaxRetryAfter(d time.Duration) Option {
+	return func(p *Policy) {
+		p.maxRetryAfter = max(d, 0)
+	}
+}
+
+// WithRetryAfter enables or disables honouring the Retry-After response header.
+func WithRetryAfter(enabled bool) Option {
+	return func(p *Policy) {
+		p.retryAfter = enabled
+	}
+}
@@ -0,0 +1,107 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultMaxAttempts = 3
+	defaultBaseDelay   = 100 * time.Millisecond
+	defaultMaxDelay    = 10 * time.Second
+
+	defaultMaxRetryAfter = time.Minute
+)
+
+// Policy describes when and how often a request should be retried.
+type Policy struct {
+	maxAttempts int
+	backoff     Backoff
+	statusCodes map[int]struct{}
+	retryAfter  bool
+
+	maxRetryAfter time.Duration
+}
+
+// NewPolicy creates a new Policy with optional configurations applied.
+// By default, a request is attempted at most 3 times using an exponential backoff
+// with full jitter, retried on transport errors and on 429, 502, 503 and 504
+// responses, and the Retry-After header is honoured up to one minute.
+func NewPolicy(opts ...Option) *Policy {
+	defaultOptions := []Option{
+		WithMaxAttempts(defaultMaxAttempts),
+		WithBackoff(ExponentialFullJitter(defaultBaseDelay, defaultMaxDelay)),
+		WithStatusCodes(
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		),
+		WithRetryAfter(true),
+		WithMaxRetryAfter(defaultMaxRetryAfter),
+	}
+
+	p := &Policy{}
+
+	for _, opt := range append(defaultOptions, opts...) {
+		opt.apply(p)
+	}
+
+	return p
+}
+
+// MaxAttempts returns the maximum number of attempts, including the first one.
+func (p *Policy) MaxAttempts() int {
+	return p.maxAttempts
+}
+
+// ShouldRetry reports whether an attempt that ended with the given response or
+// error should be retried. Context cancellation is never retried.
+func (p *Policy) ShouldRetry(res *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+
+	if res == nil {
+		return false
+	}
+
+	_, ok := p.statusCodes[res.StatusCode]
+	return ok
+}
+
+// Delay returns how long to wait before the given retry. The Retry-After header
+// of res, when present and enabled, takes precedence over the backoff. It is
+// capped to the maximum set with WithMaxRetryAfter.
+func (p *Policy) Delay(attempt int, previous time.Duration, res *http.Response, now time.Time) time.Duration {
+	if p.retryAfter && res != nil {
+		if d, ok := ParseRetryAfter(res.Header.Get("Retry-After"), now); ok {
+			return min(d, p.maxRetryAfter)
+		}
+	}
+
+	return max(p.backoff.Delay(attempt, previous), 0)
+}
+
+// ParseRetryAfter parses a Retry-After header value, either delay-seconds or an
+// HTTP-date, into the delay to wait from now.
+func ParseRetryAfter(v string, now time.Time) (time.Duration, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(v); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0), true
+	}
+
+	if t, err := http.ParseTime(v); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+
+	return 0, false
+}
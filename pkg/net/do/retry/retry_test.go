@@ -0,0 +1,66 @@
+package retry_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/merlindorin/go-shared/pkg/net/do/retry"
+)
+
+func TestBackoff(t *testing.T) {
+	t.Run("should double the exponential delay up to the maximum", func(t *testing.T) {
+		b := retry.Exponential(time.Second, 5*time.Second)
+
+		assert.Equal(t, time.Second, b.Delay(1, 0))
+		assert.Equal(t, 2*time.Second, b.Delay(2, 0))
+		assert.Equal(t, 4*time.Second, b.Delay(3, 0))
+		assert.Equal(t, 5*time.Second, b.Delay(4, 0))
+		assert.Equal(t, 5*time.Second, b.Delay(100, 0))
+	})
+
+	t.Run("should keep jittered delays within bounds", func(t *testing.T) {
+		full := retry.ExponentialFullJitter(time.Second, 5*time.Second)
+		decorrelated := retry.DecorrelatedJitter(time.Second, 5*time.Second)
+
+		for attempt := 1; attempt < 10; attempt++ {
+			assert.LessOrEqual(t, full.Delay(attempt, 0), 5*time.Second)
+			d := decorrelated.Delay(attempt, 2*time.Second)
+			assert.GreaterOrEqual(t, d, time.Second)
+			assert.LessOrEqual(t, d, 5*time.Second)
+		}
+	})
+}
+
+func TestPolicy(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("should retry transport errors and configured status codes", func(t *testing.T) {
+		p := retry.NewPolicy(retry.WithStatusCodes(http.StatusInternalServerError))
+
+		assert.True(t, p.ShouldRetry(nil, fmt.Errorf("connection reset")))
+		assert.True(t, p.ShouldRetry(&http.Response{StatusCode: http.StatusInternalServerError}, nil))
+		assert.False(t, p.ShouldRetry(&http.Response{StatusCode: http.StatusServiceUnavailable}, nil))
+	})
+
+	t.Run("should honour Retry-After", func(t *testing.T) {
+		p := retry.NewPolicy(retry.WithBackoff(retry.Constant(time.Second)))
+
+		seconds := &http.Response{Header: http.Header{"Retry-After": []string{"7"}}}
+		date := &http.Response{Header: http.Header{"Retry-After": []string{now.Add(time.Minute).Format(http.TimeFormat)}}}
+
+		assert.Equal(t, 7*time.Second, p.Delay(1, 0, seconds, now))
+		assert.Equal(t, time.Minute, p.Delay(1, 0, date, now))
+		assert.Equal(t, time.Second, p.Delay(1, 0, &http.Response{}, now))
+	})
+
+	t.Run("should cap Retry-After", func(t *testing.T) {
+		res := &http.Response{Header: http.Header{"Retry-After": []string{"86400"}}}
+
+		assert.Equal(t, time.Minute, retry.NewPolicy().Delay(1, 0, res, now))
+		assert.Equal(t, 5*time.Second, retry.NewPolicy(retry.WithMaxRetryAfter(5*time.Second)).Delay(1, 0, res, now))
+	})
+}
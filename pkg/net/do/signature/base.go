@@ -0,0 +1,122 @@
+package signature
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// signatureParamsComponent is the last line of a signature base.
+const signatureParamsComponent = "@signature-params"
+
+// signatureBase builds the signature base (RFC 9421, section 2.5) of req for the
+// given components and serialized signature parameters.
+func signatureBase(req *http.Request, components []string, params string) (string, error) {
+	var b strings.Builder
+
+	for _, component := range components {
+		value, err := componentValue(req, component)
+		if err != nil {
+			return "", err
+		}
+
+		b.WriteString(serializeString(component) + ": " + value + "\n")
+	}
+
+	b.WriteString(serializeString(signatureParamsComponent) + ": " + params)
+
+	return b.String(), nil
+}
+
+// componentValue returns the value of a derived component or a header field of req.
+func componentValue(req *http.Request, component string) (string, error) {
+	switch component {
+	case "@method":
+		return strings.ToUpper(req.Method), nil
+	case "@target-uri":
+		return scheme(req) + "://" + authority(req) + requestTarget(req), nil
+	case "@authority":
+		return authority(req), nil
+	case "@scheme":
+		return scheme(req), nil
+	case "@request-target":
+		return requestTarget(req), nil
+	case "@path":
+		if p := req.URL.EscapedPath(); p != "" {
+			return p, nil
+		}
+		return "/", nil
+	case "@query":
+		return "?" + req.URL.RawQuery, nil
+	}
+
+	if strings.HasPrefix(component, "@") {
+		return "", fmt.Errorf("unsupported derived component %q", component)
+	}
+
+	values := slices.Clone(req.Header.Values(component))
+	if component == "host" && len(values) == 0 {
+		values = []string{authority(req)}
+	}
+
+	if len(values) == 0 {
+		return "", fmt.Errorf("missing header %q", component)
+	}
+
+	for i, v := range values {
+		values[i] = strings.TrimSpace(v)
+	}
+
+	return strings.Join(values, ", "), nil
+}
+
+// scheme returns the lowercased scheme of req.
+func scheme(req *http.Request) string {
+	switch {
+	case req.URL.Scheme != "":
+		return strings.ToLower(req.URL.Scheme)
+	case req.TLS != nil:
+		return "https"
+	default:
+		return "http"
+	}
+}
+
+// authority returns the lowercased host of req, without the default port of its scheme.
+func authority(req *http.Request) string {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	host = strings.ToLower(host)
+
+	h, port, err := net.SplitHostPort(host)
+	if err != nil {
+		return host
+	}
+
+	if (port == "80" && scheme(req) == "http") || (port == "443" && scheme(req) == "https") {
+		if strings.Contains(h, ":") {
+			return "[" + h + "]"
+		}
+		return h
+	}
+
+	return host
+}
+
+// requestTarget returns the path and the query of req.
+func requestTarget(req *http.Request) string {
+	target := req.URL.EscapedPath()
+	if target == "" {
+		target = "/"
+	}
+
+	if req.URL.RawQuery != "" || req.URL.ForceQuery {
+		target += "?" + req.URL.RawQuery
+	}
+
+	return target
+}
@@ -0,0 +1,73 @@
+package signature
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+)
+
+// readSignedBody returns the body of a request to sign. Streaming bodies, whose
+// length is unknown, are rejected rather than buffered.
+func readSignedBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	if req.ContentLength < 0 || (req.ContentLength == 0 && req.GetBody == nil) {
+		return nil, ErrStreamingBody
+	}
+
+	return readBody(req, 0)
+}
+
+// readBody returns the request body and makes sure it can still be read afterwards.
+// Bodies larger than limit bytes are rejected with ErrBodyTooLarge, unless limit is zero.
+func readBody(req *http.Request, limit int64) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+
+		return readAll(body, limit)
+	}
+
+	b, err := readAll(req.Body, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = req.Body.Close(); err != nil {
+		return nil, err
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(b))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(b)), nil
+	}
+
+	return b, nil
+}
+
+// readAll reads r up to limit bytes, or entirely when limit is zero.
+func readAll(r io.Reader, limit int64) ([]byte, error) {
+	if limit <= 0 {
+		return io.ReadAll(r)
+	}
+
+	b, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(b)) > limit {
+		return nil, ErrBodyTooLarge
+	}
+
+	return b, nil
+}
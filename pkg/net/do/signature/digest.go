@@ -0,0 +1,54 @@
+package signature
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+)
+
+// ContentDigest returns the Content-Digest header value (RFC 9530) of body using sha-256.
+func ContentDigest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return "sha-256=:" + base64.StdEncoding.EncodeToString(sum[:]) + ":"
+}
+
+// verifyContentDigest checks the sha-256 and sha-512 digests of a Content-Digest
+// header value against body. At least one supported digest must be present.
+func verifyContentDigest(value string, body []byte) error {
+	members, err := parseDictionary(value)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrContentDigest, err)
+	}
+
+	var checked bool
+
+	for _, m := range members {
+		var sum []byte
+
+		switch m.key {
+		case "sha-256":
+			s := sha256.Sum256(body)
+			sum = s[:]
+		case "sha-512":
+			s := sha512.Sum512(body)
+			sum = s[:]
+		default:
+			continue
+		}
+
+		digest, er := parseByteSequence(m.value)
+		if er != nil || !bytes.Equal(digest, sum) {
+			return ErrContentDigest
+		}
+
+		checked = true
+	}
+
+	if !checked {
+		return fmt.Errorf("%w: no supported digest algorithm", ErrContentDigest)
+	}
+
+	return nil
+}
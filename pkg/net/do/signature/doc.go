@@ -0,0 +1,16 @@
+// Package signature signs and verifies HTTP requests. It provides an HMAC-SHA256
+// header signer for webhooks and an implementation of HTTP Message Signatures
+// (RFC 9421) with Ed25519, RSA-PSS and HMAC keys, along with the Content-Digest
+// header (RFC 9530) to cover the request body.
+//
+// Signers are installed on clients with do.WithSigner, verifiers check incoming
+// requests on the server side, directly or through Middleware:
+//
+//	signer := signature.NewMessageSigner("key-1", signature.NewEd25519Key(privateKey))
+//	client := rest.NewRest(baseURL, do.WithSigner(signer))
+//
+//	verifier := signature.NewMessageVerifier(func(keyID string) (signature.Key, error) {
+//	    return signature.NewEd25519PublicKey(publicKey), nil
+//	})
+//	handler := signature.Middleware(verifier, mux)
+package signature
@@ -0,0 +1,16 @@
+package signature
+
+import "errors"
+
+// Errors returned by the verifiers.
+var (
+	ErrMissingSignature = errors.New("missing signature")
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrExpiredSignature = errors.New("expired signature")
+	ErrContentDigest    = errors.New("content digest mismatch")
+	ErrBodyTooLarge     = errors.New("request body too large")
+)
+
+// ErrStreamingBody is returned by the signers when the request body has an
+// unknown length, such as a multipart body, since it would have to be buffered.
+var ErrStreamingBody = errors.New("cannot sign a streaming request body")
@@ -0,0 +1,99 @@
+package signature
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultHMACHeader is the default header carrying HMAC signatures.
+const DefaultHMACHeader = "X-Signature"
+
+// hmacPrefix prefixes the hex encoded HMAC signatures.
+const hmacPrefix = "sha256="
+
+// HMAC signs requests with an HMAC-SHA256 of their body, sent as "sha256=<hex>"
+// in a header. With WithTimestampHeader, the signed message is the timestamp,
+// a dot and the body.
+type HMAC struct {
+	key []byte
+	cfg config
+}
+
+// NewHMAC creates an HMAC signer and verifier with the given shared secret.
+// It uses WithHeader, WithTimestampHeader, WithTolerance, WithMaxBodySize and WithNow.
+func NewHMAC(key []byte, opts ...Option) *HMAC {
+	return &HMAC{key: key, cfg: newConfig(opts...)}
+}
+
+// Sign sets the signature header, and the timestamp header when enabled.
+func (h *HMAC) Sign(req *http.Request) error {
+	body, err := readSignedBody(req)
+	if err != nil {
+		return err
+	}
+
+	var timestamp string
+	if h.cfg.timestampHeader != "" {
+		timestamp = strconv.FormatInt(h.cfg.now().Unix(), 10)
+		req.Header.Set(h.cfg.timestampHeader, timestamp)
+	}
+
+	req.Header.Set(h.cfg.header, hmacPrefix+hex.EncodeToString(h.mac(timestamp, body)))
+
+	return nil
+}
+
+// Verify checks the signature header of req, and the age of the timestamp when enabled.
+func (h *HMAC) Verify(req *http.Request) error {
+	value := req.Header.Get(h.cfg.header)
+	if value == "" {
+		return ErrMissingSignature
+	}
+
+	sig, err := hex.DecodeString(strings.TrimPrefix(value, hmacPrefix))
+	if err != nil || !strings.HasPrefix(value, hmacPrefix) {
+		return fmt.Errorf("%w: malformed %s header", ErrInvalidSignature, h.cfg.header)
+	}
+
+	var timestamp string
+	if h.cfg.timestampHeader != "" {
+		timestamp = req.Header.Get(h.cfg.timestampHeader)
+
+		unix, er := strconv.ParseInt(timestamp, 10, 64)
+		if er != nil {
+			return fmt.Errorf("%w: malformed %s header", ErrInvalidSignature, h.cfg.timestampHeader)
+		}
+
+		if h.cfg.tolerance > 0 && h.cfg.now().Sub(time.Unix(unix, 0)).Abs() > h.cfg.tolerance {
+			return ErrExpiredSignature
+		}
+	}
+
+	body, err := readBody(req, h.cfg.maxBodySize)
+	if err != nil {
+		return err
+	}
+
+	if !hmac.Equal(sig, h.mac(timestamp, body)) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// mac computes the HMAC of the timestamp and the body.
+func (h *HMAC) mac(timestamp string, body []byte) []byte {
+	m := hmac.New(sha256.New, h.key)
+	if timestamp != "" {
+		m.Write([]byte(timestamp + "."))
+	}
+	m.Write(body)
+
+	return m.Sum(nil)
+}
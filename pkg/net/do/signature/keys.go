@@ -0,0 +1,144 @@
+package signature
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+)
+
+// Algorithm names of the HTTP Signature Algorithms registry.
+const (
+	AlgorithmEd25519      = "ed25519"
+	AlgorithmRSAPSSSHA512 = "rsa-pss-sha512"
+	AlgorithmHMACSHA256   = "hmac-sha256"
+)
+
+// rsaPSSSaltLength is the salt length required by rsa-pss-sha512.
+const rsaPSSSaltLength = 64
+
+// errVerifyOnly is returned when signing with a public key.
+var errVerifyOnly = errors.New("cannot sign with a public key")
+
+// Key signs and verifies message signatures with an algorithm.
+type Key interface {
+	// Algorithm returns the name of the algorithm.
+	Algorithm() string
+	// Sign signs data.
+	Sign(data []byte) ([]byte, error)
+	// Verify returns ErrInvalidSignature when sig is not a signature of data.
+	Verify(data, sig []byte) error
+}
+
+// ed25519Key is an Ed25519 key, private is nil for verification only keys.
+type ed25519Key struct {
+	private ed25519.PrivateKey
+	public  ed25519.PublicKey
+}
+
+// NewEd25519Key returns an ed25519 Key signing with the given private key.
+func NewEd25519Key(private ed25519.PrivateKey) Key {
+	public, _ := private.Public().(ed25519.PublicKey)
+	return ed25519Key{private: private, public: public}
+}
+
+// NewEd25519PublicKey returns an ed25519 Key verifying with the given public key.
+func NewEd25519PublicKey(public ed25519.PublicKey) Key {
+	return ed25519Key{public: public}
+}
+
+func (k ed25519Key) Algorithm() string {
+	return AlgorithmEd25519
+}
+
+func (k ed25519Key) Sign(data []byte) ([]byte, error) {
+	if k.private == nil {
+		return nil, errVerifyOnly
+	}
+
+	return ed25519.Sign(k.private, data), nil
+}
+
+func (k ed25519Key) Verify(data, sig []byte) error {
+	if !ed25519.Verify(k.public, data, sig) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// rsaPSSKey is an RSA-PSS key using SHA-512, private is nil for verification only keys.
+type rsaPSSKey struct {
+	private *rsa.PrivateKey
+	public  *rsa.PublicKey
+}
+
+// NewRSAPSSKey returns an rsa-pss-sha512 Key signing with the given private key.
+func NewRSAPSSKey(private *rsa.PrivateKey) Key {
+	return rsaPSSKey{private: private, public: &private.PublicKey}
+}
+
+// NewRSAPSSPublicKey returns an rsa-pss-sha512 Key verifying with the given public key.
+func NewRSAPSSPublicKey(public *rsa.PublicKey) Key {
+	return rsaPSSKey{public: public}
+}
+
+func (k rsaPSSKey) Algorithm() string {
+	return AlgorithmRSAPSSSHA512
+}
+
+func (k rsaPSSKey) Sign(data []byte) ([]byte, error) {
+	if k.private == nil {
+		return nil, errVerifyOnly
+	}
+
+	digest := sha512.Sum512(data)
+	return rsa.SignPSS(rand.Reader, k.private, crypto.SHA512, digest[:], &rsa.PSSOptions{
+		SaltLength: rsaPSSSaltLength,
+		Hash:       crypto.SHA512,
+	})
+}
+
+func (k rsaPSSKey) Verify(data, sig []byte) error {
+	digest := sha512.Sum512(data)
+	err := rsa.VerifyPSS(k.public, crypto.SHA512, digest[:], sig, &rsa.PSSOptions{
+		SaltLength: rsaPSSSaltLength,
+		Hash:       crypto.SHA512,
+	})
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// hmacKey is an HMAC-SHA256 shared secret.
+type hmacKey []byte
+
+// NewHMACKey returns an hmac-sha256 Key with the given shared secret.
+func NewHMACKey(secret []byte) Key {
+	return hmacKey(secret)
+}
+
+func (k hmacKey) Algorithm() string {
+	return AlgorithmHMACSHA256
+}
+
+func (k hmacKey) Sign(data []byte) ([]byte, error) {
+	m := hmac.New(sha256.New, k)
+	m.Write(data)
+	return m.Sum(nil), nil
+}
+
+func (k hmacKey) Verify(data, sig []byte) error {
+	expected, _ := k.Sign(data)
+	if !hmac.Equal(expected, sig) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
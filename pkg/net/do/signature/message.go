@@ -0,0 +1,75 @@
+package signature
+
+import (
+	"encoding/base64"
+	"net/http"
+	"slices"
+)
+
+// DefaultLabel is the default label of message signatures.
+const DefaultLabel = "sig1"
+
+// contentDigestComponent is the component covering the Content-Digest header.
+const contentDigestComponent = "content-digest"
+
+// MessageSigner signs requests with HTTP Message Signatures (RFC 9421), setting
+// the Signature-Input and Signature headers.
+type MessageSigner struct {
+	keyID string
+	key   Key
+	cfg   config
+}
+
+// NewMessageSigner creates a MessageSigner with the given key identifier and key.
+// It uses WithLabel, WithComponents, WithExpires, WithContentDigest, WithAlgParameter
+// and WithNow.
+func NewMessageSigner(keyID string, key Key, opts ...Option) *MessageSigner {
+	return &MessageSigner{keyID: keyID, key: key, cfg: newConfig(opts...)}
+}
+
+// Sign signs the request. When the request has a body and content digests are
+// enabled, the Content-Digest header is set and covered by the signature.
+func (s *MessageSigner) Sign(req *http.Request) error {
+	components := slices.Clone(s.cfg.components)
+
+	if s.cfg.digest {
+		body, err := readSignedBody(req)
+		if err != nil {
+			return err
+		}
+
+		if body != nil {
+			req.Header.Set("Content-Digest", ContentDigest(body))
+			if !slices.Contains(components, contentDigestComponent) {
+				components = append(components, contentDigestComponent)
+			}
+		}
+	}
+
+	created := s.cfg.now()
+	params := []parameter{{key: "created", value: created.Unix()}}
+	if s.cfg.expires > 0 {
+		params = append(params, parameter{key: "expires", value: created.Add(s.cfg.expires).Unix()})
+	}
+	params = append(params, parameter{key: "keyid", value: s.keyID})
+	if s.cfg.algParam {
+		params = append(params, parameter{key: "alg", value: s.key.Algorithm()})
+	}
+
+	signatureParams := serializeInnerList(components, params)
+
+	base, err := signatureBase(req, components, signatureParams)
+	if err != nil {
+		return err
+	}
+
+	sig, err := s.key.Sign([]byte(base))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Signature-Input", s.cfg.label+"="+signatureParams)
+	req.Header.Set("Signature", s.cfg.label+"=:"+base64.StdEncoding.EncodeToString(sig)+":")
+
+	return nil
+}
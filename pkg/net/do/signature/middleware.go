@@ -0,0 +1,25 @@
+package signature
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Middleware rejects requests whose signature cannot be verified by v with
+// 401 Unauthorized, or 413 Request Entity Too Large when the body exceeds the
+// limit of the verifier, and passes the others to next.
+func Middleware(v Verifier, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := v.Verify(r); err != nil {
+			status := http.StatusUnauthorized
+			if errors.Is(err, ErrBodyTooLarge) {
+				status = http.StatusRequestEntityTooLarge
+			}
+
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,154 @@
+package signature
+
+import (
+	"strings"
+	"time"
+)
+
+// DefaultMaxBodySize is the default maximum size of the request bodies read by the verifiers.
+const DefaultMaxBodySize = 10 << 20
+
+// config holds the settings shared by the signers and verifiers of the package.
+type config struct {
+	now func() time.Time
+
+	header          string
+	timestampHeader string
+	tolerance       time.Duration
+	maxBodySize     int64
+
+	label      string
+	labelSet   bool
+	components []string
+	required   []string
+	expires    time.Duration
+	digest     bool
+	algParam   bool
+}
+
+// Option represents a configuration setting that can be applied to the signers
+// and verifiers of the package. Each option documents what it applies to.
+type Option func(c *config)
+
+// apply sets the given Option to the config.
+func (o Option) apply(c *config) {
+	o(c)
+}
+
+// newConfig creates a config with the default settings and the given options applied.
+func newConfig(opts ...Option) config {
+	c := config{
+		now:         time.Now,
+		header:      DefaultHMACHeader,
+		tolerance:   5 * time.Minute,
+		maxBodySize: DefaultMaxBodySize,
+		label:       DefaultLabel,
+		components:  []string{"@method", "@target-uri"},
+		digest:      true,
+		algParam:    true,
+	}
+
+	for _, opt := range opts {
+		opt.apply(&c)
+	}
+
+	return c
+}
+
+// WithNow sets the time function used for timestamps and their validation.
+func WithNow(fn func() time.Time) Option {
+	return func(c *config) {
+		c.now = fn
+	}
+}
+
+// WithHeader sets the header carrying the HMAC signature, X-Signature by default.
+func WithHeader(header string) Option {
+	return func(c *config) {
+		c.header = header
+	}
+}
+
+// WithTimestampHeader includes a Unix timestamp in the HMAC signature, sent in the
+// given header, so that verifiers can reject replayed requests.
+func WithTimestampHeader(header string) Option {
+	return func(c *config) {
+		c.timestampHeader = header
+	}
+}
+
+// WithTolerance sets the maximum age of a signature accepted by the verifiers,
+// and how far in the future its creation time may be to allow for clock skew,
+// 5 minutes by default. Zero disables the checks.
+func WithTolerance(d time.Duration) Option {
+	return func(c *config) {
+		c.tolerance = d
+	}
+}
+
+// WithMaxBodySize sets the maximum size of the request bodies read by the
+// verifiers, DefaultMaxBodySize by default. Zero disables the limit.
+func WithMaxBodySize(n int64) Option {
+	return func(c *config) {
+		c.maxBodySize = n
+	}
+}
+
+// WithLabel sets the label of the message signature, sig1 by default. Verifiers
+// only check the signature with this label when it is set explicitly.
+func WithLabel(label string) Option {
+	return func(c *config) {
+		c.label = label
+		c.labelSet = true
+	}
+}
+
+// WithComponents sets the components covered by the message signature,
+// "@method" and "@target-uri" by default. Derived components start with "@",
+// other components are header field names, which are lowercased.
+func WithComponents(components ...string) Option {
+	return func(c *config) {
+		c.components = lowercase(components)
+	}
+}
+
+// WithRequiredComponents sets the components a message signature must cover to
+// be accepted by the verifier. Header field names are lowercased.
+func WithRequiredComponents(components ...string) Option {
+	return func(c *config) {
+		c.required = lowercase(components)
+	}
+}
+
+// lowercase returns a copy of the component names in lower case, as RFC 9421 requires.
+func lowercase(components []string) []string {
+	lowered := make([]string, len(components))
+	for i, component := range components {
+		lowered[i] = strings.ToLower(component)
+	}
+
+	return lowered
+}
+
+// WithExpires sets the validity of message signatures through the expires parameter.
+func WithExpires(d time.Duration) Option {
+	return func(c *config) {
+		c.expires = d
+	}
+}
+
+// WithContentDigest enables or disables the Content-Digest header, which is
+// added and covered by message signatures of requests with a body by default.
+func WithContentDigest(enabled bool) Option {
+	return func(c *config) {
+		c.digest = enabled
+	}
+}
+
+// WithAlgParameter enables or disables the alg parameter of message signatures,
+// included by default.
+func WithAlgParameter(enabled bool) Option {
+	return func(c *config) {
+		c.algParam = enabled
+	}
+}
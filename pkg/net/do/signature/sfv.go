@@ -0,0 +1,218 @@
+package signature
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// This file implements the subset of Structured Field Values (RFC 8941) used by
+// the Signature-Input, Signature and Content-Digest headers.
+
+// errMalformed is returned for structured field values that cannot be parsed.
+var errMalformed = errors.New("malformed structured field")
+
+// member is a member of a dictionary, value is kept unparsed.
+type member struct {
+	key   string
+	value string
+}
+
+// parameter is a parameter of an inner list.
+type parameter struct {
+	key   string
+	value any
+}
+
+// parseDictionary splits a dictionary into its members, keeping their order.
+func parseDictionary(s string) ([]member, error) {
+	var members []member
+
+	for _, raw := range splitTopLevel(s, ',') {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(raw, "=")
+		if !ok || key == "" {
+			return nil, errMalformed
+		}
+
+		members = append(members, member{key: strings.TrimSpace(key), value: strings.TrimSpace(value)})
+	}
+
+	return members, nil
+}
+
+// parseByteSequence decodes a ":base64:" byte sequence.
+func parseByteSequence(s string) ([]byte, error) {
+	if len(s) < 2 || s[0] != ':' || s[len(s)-1] != ':' {
+		return nil, errMalformed
+	}
+
+	return base64.StdEncoding.DecodeString(s[1 : len(s)-1])
+}
+
+// parseInnerList parses an inner list of strings followed by its parameters.
+// Items with parameters are not supported.
+func parseInnerList(s string) ([]string, []parameter, error) {
+	if !strings.HasPrefix(s, "(") {
+		return nil, nil, errMalformed
+	}
+
+	end := indexTopLevel(s, ')')
+	if end < 0 {
+		return nil, nil, errMalformed
+	}
+
+	var items []string
+	for _, field := range splitTopLevel(s[1:end], ' ') {
+		if field == "" {
+			continue
+		}
+
+		item, err := parseString(field)
+		if err != nil {
+			return nil, nil, err
+		}
+		items = append(items, item)
+	}
+
+	params, err := parseParameters(s[end+1:])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return items, params, nil
+}
+
+// parseParameters parses ";key=value" parameters, values are strings, integers or tokens.
+func parseParameters(s string) ([]parameter, error) {
+	var params []parameter
+
+	for _, raw := range splitTopLevel(s, ';') {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(raw, "=")
+		if !ok {
+			params = append(params, parameter{key: key, value: true})
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(value, `"`):
+			str, err := parseString(value)
+			if err != nil {
+				return nil, err
+			}
+			params = append(params, parameter{key: key, value: str})
+		default:
+			if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+				params = append(params, parameter{key: key, value: n})
+				continue
+			}
+			params = append(params, parameter{key: key, value: value})
+		}
+	}
+
+	return params, nil
+}
+
+// parseString decodes a quoted string.
+func parseString(s string) (string, error) {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return "", errMalformed
+	}
+
+	var b strings.Builder
+	for i := 1; i < len(s)-1; i++ {
+		if s[i] == '\\' {
+			i++
+			if i >= len(s)-1 {
+				return "", errMalformed
+			}
+		}
+		b.WriteByte(s[i])
+	}
+
+	return b.String(), nil
+}
+
+// serializeString encodes a quoted string.
+func serializeString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// serializeInnerList encodes an inner list of strings followed by its parameters.
+func serializeInnerList(items []string, params []parameter) string {
+	quoted := make([]string, 0, len(items))
+	for _, item := range items {
+		quoted = append(quoted, serializeString(item))
+	}
+
+	var b strings.Builder
+	b.WriteString("(" + strings.Join(quoted, " ") + ")")
+
+	for _, p := range params {
+		b.WriteString(";" + p.key + "=")
+
+		switch v := p.value.(type) {
+		case int64:
+			b.WriteString(strconv.FormatInt(v, 10))
+		case string:
+			b.WriteString(serializeString(v))
+		}
+	}
+
+	return b.String()
+}
+
+// splitTopLevel splits s on sep, ignoring separators within strings and inner lists.
+func splitTopLevel(s string, sep byte) []string {
+	var (
+		parts []string
+		start int
+	)
+
+	for {
+		i := indexTopLevel(s[start:], sep)
+		if i < 0 {
+			return append(parts, s[start:])
+		}
+
+		parts = append(parts, s[start:start+i])
+		start += i + 1
+	}
+}
+
+// indexTopLevel returns the index of the first c outside strings and, unless c
+// closes it, inner lists.
+func indexTopLevel(s string, c byte) int {
+	var (
+		inString bool
+		depth    int
+	)
+
+	for i := 0; i < len(s); i++ {
+		switch {
+		case inString && s[i] == '\\':
+			i++
+		case s[i] == '"':
+			inString = !inString
+		case inString:
+		case s[i] == c && (depth == 0 || (c == ')' && depth == 1)):
+			return i
+		case s[i] == '(':
+			depth++
+		case s[i] == ')':
+			depth--
+		}
+	}
+
+	return -1
+}
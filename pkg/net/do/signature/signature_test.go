@@ -0,0 +1,279 @@
+package signature_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/merlindorin/go-shared/pkg/must"
+	"github.com/merlindorin/go-shared/pkg/net/do"
+	"github.com/merlindorin/go-shared/pkg/net/do/signature"
+)
+
+// testKeyEd25519 is the test-key-ed25519 private key of RFC 9421, appendix B.1.4.
+const testKeyEd25519 = "MC4CAQAwBQYDK2VwBCIEIJ+DYvh6SEqVTm50DFtMDoQikTmiCqirVv9mWG9qfSnF"
+
+func TestMessageSigner(t *testing.T) {
+	t.Run("should match the RFC 9421 Ed25519 example", func(t *testing.T) {
+		der := must.Get(base64.StdEncoding.DecodeString(testKeyEd25519))
+		private, ok := must.Get(x509.ParsePKCS8PrivateKey(der)).(ed25519.PrivateKey)
+		require.True(t, ok)
+
+		req := httptest.NewRequest(
+			http.MethodPost,
+			"http://example.com/foo?param=Value&Pet=dog",
+			strings.NewReader(`{"hello": "world"}`),
+		)
+		req.Header.Set("Date", "Tue, 20 Apr 2021 02:07:55 GMT")
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Content-Length", "18")
+
+		signer := signature.NewMessageSigner(
+			"test-key-ed25519",
+			signature.NewEd25519Key(private),
+			signature.WithLabel("sig-b26"),
+			signature.WithComponents("date", "@method", "@path", "@authority", "content-type", "content-length"),
+			signature.WithContentDigest(false),
+			signature.WithAlgParameter(false),
+			signature.WithNow(func() time.Time { return time.Unix(1618884473, 0) }),
+		)
+
+		require.NoError(t, signer.Sign(req))
+		assert.Equal(t,
+			`sig-b26=("date" "@method" "@path" "@authority" "content-type" "content-length");`+
+				`created=1618884473;keyid="test-key-ed25519"`,
+			req.Header.Get("Signature-Input"),
+		)
+		assert.Equal(t,
+			"sig-b26=:wqcAqbmYJ2ji2glfAMaRy4gruYYnx2nEFN2HN6jrnDnQCK1u02Gb04v9EDgwUPiu4A0w6vuQv5lIp5WPpBKRCw==:",
+			req.Header.Get("Signature"),
+		)
+	})
+}
+
+func TestMessageVerifier(t *testing.T) {
+	rsaKey := must.Get(rsa.GenerateKey(rand.Reader, 2048))
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	keys := map[string]signature.Key{
+		"ed":   signature.NewEd25519PublicKey(public),
+		"rsa":  signature.NewRSAPSSPublicKey(&rsaKey.PublicKey),
+		"hmac": signature.NewHMACKey([]byte("secret")),
+	}
+	resolve := func(keyID string) (signature.Key, error) {
+		if k, ok := keys[keyID]; ok {
+			return k, nil
+		}
+		return nil, errors.New("unknown key")
+	}
+
+	signers := map[string]*signature.MessageSigner{
+		"ed":  signature.NewMessageSigner("ed", signature.NewEd25519Key(private)),
+		"rsa": signature.NewMessageSigner("rsa", signature.NewRSAPSSKey(rsaKey)),
+		"hmac": signature.NewMessageSigner(
+			"hmac",
+			signature.NewHMACKey([]byte("secret")),
+			signature.WithExpires(time.Minute),
+		),
+	}
+
+	for name, signer := range signers {
+		t.Run("should verify requests signed by the client with "+name, func(t *testing.T) {
+			var verified bool
+			srv := httptest.NewServer(signature.Middleware(
+				signature.NewMessageVerifier(resolve, signature.WithRequiredComponents("@method", "content-digest")),
+				http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+					verified = true
+					w.WriteHeader(http.StatusNoContent)
+				}),
+			))
+			defer srv.Close()
+
+			err := do.Do(
+				context.TODO(),
+				must.Get(url.Parse(srv.URL)),
+				do.WithMethod(http.MethodPost),
+				do.WithStatusCheck(),
+				do.WithMarshalBody(map[string]string{"hello": "world"}),
+				do.WithSigner(signer),
+			)
+
+			assert.NoError(t, err)
+			assert.True(t, verified)
+		})
+	}
+
+	t.Run("should reject tampered requests", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "https://example.com/foo", strings.NewReader("body"))
+		require.NoError(t, signers["ed"].Sign(req))
+
+		verifier := signature.NewMessageVerifier(resolve)
+
+		tampered := req.Clone(context.TODO())
+		tampered.Method = http.MethodPut
+		assert.ErrorIs(t, verifier.Verify(tampered), signature.ErrInvalidSignature)
+
+		tampered = req.Clone(context.TODO())
+		tampered.Body, tampered.GetBody = io.NopCloser(strings.NewReader("other")), nil
+		assert.ErrorIs(t, verifier.Verify(tampered), signature.ErrContentDigest)
+
+		assert.ErrorIs(t, verifier.Verify(httptest.NewRequest(http.MethodGet, "/", nil)), signature.ErrMissingSignature)
+	})
+
+	t.Run("should reject expired signatures", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "https://example.com/foo", nil)
+		require.NoError(t, signers["hmac"].Sign(req))
+
+		verifier := signature.NewMessageVerifier(resolve, signature.WithNow(func() time.Time {
+			return time.Now().Add(2 * time.Minute)
+		}))
+
+		assert.ErrorIs(t, verifier.Verify(req), signature.ErrExpiredSignature)
+	})
+
+	t.Run("should reject signatures created in the future", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "https://example.com/foo", nil)
+		require.NoError(t, signers["hmac"].Sign(req))
+
+		verifier := signature.NewMessageVerifier(resolve, signature.WithNow(func() time.Time {
+			return time.Now().Add(-10 * time.Minute)
+		}))
+
+		assert.ErrorIs(t, verifier.Verify(req), signature.ErrInvalidSignature)
+	})
+
+	t.Run("should accept signatures created slightly in the future", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "https://example.com/foo", nil)
+		require.NoError(t, signers["hmac"].Sign(req))
+
+		verifier := signature.NewMessageVerifier(resolve, signature.WithNow(func() time.Time {
+			return time.Now().Add(-2 * time.Second)
+		}))
+
+		assert.NoError(t, verifier.Verify(req))
+	})
+
+	t.Run("should cover header fields given in any case without altering them", func(t *testing.T) {
+		signer := signature.NewMessageSigner(
+			"ed",
+			signature.NewEd25519Key(private),
+			signature.WithComponents("@method", "X-Tenant"),
+		)
+
+		req := httptest.NewRequest(http.MethodGet, "https://example.com/foo", nil)
+		req.Header.Add("X-Tenant", " a ")
+		req.Header.Add("X-Tenant", "b")
+		require.NoError(t, signer.Sign(req))
+
+		assert.Contains(t, req.Header.Get("Signature-Input"), `("@method" "x-tenant")`)
+		assert.Equal(t, []string{" a ", "b"}, req.Header.Values("X-Tenant"))
+
+		verifier := signature.NewMessageVerifier(resolve, signature.WithRequiredComponents("X-Tenant"))
+		assert.NoError(t, verifier.Verify(req))
+	})
+
+	t.Run("should reject signatures without created parameter", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "https://example.com/foo", nil)
+		req.Header.Set("Signature-Input", `sig1=("@method");keyid="hmac"`)
+		req.Header.Set("Signature", "sig1=:AAAA:")
+
+		err := signature.NewMessageVerifier(resolve).Verify(req)
+
+		assert.ErrorIs(t, err, signature.ErrInvalidSignature)
+		assert.ErrorContains(t, err, "missing created parameter")
+	})
+
+	t.Run("should reject bodies larger than the limit", func(t *testing.T) {
+		srv := httptest.NewServer(signature.Middleware(
+			signature.NewMessageVerifier(resolve, signature.WithMaxBodySize(16)),
+			http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			}),
+		))
+		defer srv.Close()
+
+		err := do.Do(
+			context.TODO(),
+			must.Get(url.Parse(srv.URL)),
+			do.WithMethod(http.MethodPost),
+			do.WithStatusCheck(),
+			do.WithBody(strings.NewReader(strings.Repeat("x", 17))),
+			do.WithSigner(signers["ed"]),
+		)
+
+		var httpErr *do.HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, httpErr.StatusCode)
+	})
+}
+
+func TestHMAC(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	h := signature.NewHMAC(
+		[]byte("secret"),
+		signature.WithTimestampHeader("X-Timestamp"),
+		signature.WithNow(func() time.Time { return now }),
+	)
+
+	req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(`{"event":"push"}`))
+	require.NoError(t, h.Sign(req))
+
+	assert.Equal(t, "1700000000", req.Header.Get("X-Timestamp"))
+	assert.True(t, strings.HasPrefix(req.Header.Get(signature.DefaultHMACHeader), "sha256="))
+	assert.NoError(t, h.Verify(req))
+
+	other := signature.NewHMAC(
+		[]byte("other"),
+		signature.WithTimestampHeader("X-Timestamp"),
+		signature.WithNow(func() time.Time { return now }),
+	)
+	assert.ErrorIs(t, other.Verify(req), signature.ErrInvalidSignature)
+
+	later := signature.NewHMAC(
+		[]byte("secret"),
+		signature.WithTimestampHeader("X-Timestamp"),
+		signature.WithNow(func() time.Time { return now.Add(time.Hour) }),
+	)
+	assert.ErrorIs(t, later.Verify(req), signature.ErrExpiredSignature)
+}
+
+func TestStreamingBody(t *testing.T) {
+	t.Run("should reject multipart bodies instead of buffering them", func(t *testing.T) {
+		var called bool
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			called = true
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer srv.Close()
+
+		err := do.Do(
+			context.TODO(),
+			must.Get(url.Parse(srv.URL)),
+			do.WithMethod(http.MethodPost),
+			do.WithMultipart(do.MultipartFile{
+				Field:    "file",
+				Filename: "file.bin",
+				Reader:   io.MultiReader(strings.NewReader(strings.Repeat("x", 200000))),
+			}),
+			do.WithSigner(signature.NewHMAC([]byte("secret"))),
+		)
+
+		assert.ErrorIs(t, err, signature.ErrStreamingBody)
+		assert.False(t, called)
+	})
+}
@@ -0,0 +1,168 @@
+package signature
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// KeyResolver returns the key identified by keyID.
+type KeyResolver func(keyID string) (Key, error)
+
+// Verifier verifies the signature of a request.
+type Verifier interface {
+	Verify(req *http.Request) error
+}
+
+// MessageVerifier verifies HTTP Message Signatures (RFC 9421).
+type MessageVerifier struct {
+	resolve KeyResolver
+	label   string
+	cfg     config
+}
+
+// NewMessageVerifier creates a MessageVerifier resolving keys with resolve.
+// It uses WithLabel, WithRequiredComponents, WithTolerance, WithMaxBodySize and WithNow.
+func NewMessageVerifier(resolve KeyResolver, opts ...Option) *MessageVerifier {
+	v := &MessageVerifier{resolve: resolve, cfg: newConfig(opts...)}
+
+	if v.cfg.labelSet {
+		v.label = v.cfg.label
+	}
+
+	return v
+}
+
+// Verify checks the signature of req with the configured label, or its first
+// signature. When the Content-Digest header is covered, it is checked against
+// the body.
+func (v *MessageVerifier) Verify(req *http.Request) error {
+	label, signatureParams, sig, err := v.signature(req)
+	if err != nil {
+		return err
+	}
+
+	components, params, err := parseInnerList(signatureParams)
+	if err != nil {
+		return fmt.Errorf("%w: malformed Signature-Input %q: %w", ErrInvalidSignature, label, err)
+	}
+
+	for _, required := range v.cfg.required {
+		if !slices.Contains(components, required) {
+			return fmt.Errorf("%w: component %q is not covered", ErrInvalidSignature, required)
+		}
+	}
+
+	key, err := v.checkParameters(params)
+	if err != nil {
+		return err
+	}
+
+	base, err := signatureBase(req, components, signatureParams)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+
+	if err = key.Verify([]byte(base), sig); err != nil {
+		return err
+	}
+
+	if slices.Contains(components, contentDigestComponent) {
+		body, er := readBody(req, v.cfg.maxBodySize)
+		if er != nil {
+			return er
+		}
+
+		return verifyContentDigest(req.Header.Get("Content-Digest"), body)
+	}
+
+	return nil
+}
+
+// signature returns the label, the serialized parameters and the signature to verify.
+func (v *MessageVerifier) signature(req *http.Request) (string, string, []byte, error) {
+	inputs, err := parseDictionary(strings.Join(req.Header.Values("Signature-Input"), ", "))
+	if err != nil {
+		return "", "", nil, fmt.Errorf("%w: malformed Signature-Input: %w", ErrInvalidSignature, err)
+	}
+
+	signatures, err := parseDictionary(strings.Join(req.Header.Values("Signature"), ", "))
+	if err != nil {
+		return "", "", nil, fmt.Errorf("%w: malformed Signature: %w", ErrInvalidSignature, err)
+	}
+
+	for _, input := range inputs {
+		if v.label != "" && input.key != v.label {
+			continue
+		}
+
+		i := slices.IndexFunc(signatures, func(m member) bool { return m.key == input.key })
+		if i < 0 {
+			continue
+		}
+
+		sig, er := parseByteSequence(signatures[i].value)
+		if er != nil {
+			return "", "", nil, fmt.Errorf("%w: malformed Signature %q", ErrInvalidSignature, input.key)
+		}
+
+		return input.key, input.value, sig, nil
+	}
+
+	return "", "", nil, ErrMissingSignature
+}
+
+// checkParameters validates the signature parameters and resolves the key. The
+// created parameter is required, and rejected when it is further in the future
+// or older than the tolerance.
+func (v *MessageVerifier) checkParameters(params []parameter) (Key, error) {
+	var (
+		keyID, alg string
+		hasCreated bool
+		now        = v.cfg.now()
+	)
+
+	for _, p := range params {
+		switch p.key {
+		case "keyid":
+			keyID, _ = p.value.(string)
+		case "alg":
+			alg, _ = p.value.(string)
+		case "created":
+			created, ok := p.value.(int64)
+			if !ok {
+				return nil, fmt.Errorf("%w: malformed created parameter", ErrInvalidSignature)
+			}
+			age := now.Sub(time.Unix(created, 0))
+			if v.cfg.tolerance > 0 && -age > v.cfg.tolerance {
+				return nil, fmt.Errorf("%w: created in the future", ErrInvalidSignature)
+			}
+			if v.cfg.tolerance > 0 && age > v.cfg.tolerance {
+				return nil, ErrExpiredSignature
+			}
+			hasCreated = true
+		case "expires":
+			expires, _ := p.value.(int64)
+			if now.After(time.Unix(expires, 0)) {
+				return nil, ErrExpiredSignature
+			}
+		}
+	}
+
+	if !hasCreated {
+		return nil, fmt.Errorf("%w: missing created parameter", ErrInvalidSignature)
+	}
+
+	key, err := v.resolve(keyID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: cannot resolve key %q: %w", ErrInvalidSignature, keyID, err)
+	}
+
+	if alg != "" && alg != key.Algorithm() {
+		return nil, fmt.Errorf("%w: unexpected algorithm %q", ErrInvalidSignature, alg)
+	}
+
+	return key, nil
+}
@@ -0,0 +1,34 @@
+package do
+
+import (
+	"context"
+	"net/http"
+)
+
+// Signer signs a request. It runs after the body has been set, and signers
+// needing the body read it through req.GetBody. Streaming bodies of unknown
+// length, such as the ones of WithMultipart, should be rejected rather than
+// buffered.
+type Signer interface {
+	Sign(req *http.Request) error
+}
+
+// SignerFunc is an adapter to allow the use of ordinary functions as Signer.
+type SignerFunc func(req *http.Request) error
+
+// Sign calls f(req).
+func (f SignerFunc) Sign(req *http.Request) error {
+	return f(req)
+}
+
+// WithSigner signs the request with the given Signer, after the body marshalling
+// and the other pre-request handlers.
+func WithSigner(signer Signer) Option {
+	return WithPreRequestHandler(
+		SignerHandler,
+		func(_ context.Context, req *http.Request) error {
+			return signer.Sign(req)
+		},
+		Priority(PrioritySigning),
+	)
+}
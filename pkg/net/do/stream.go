@@ -0,0 +1,311 @@
+package do
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EventStreamContentType is the media type of Server-Sent Events.
+const EventStreamContentType = "text/event-stream"
+
+const (
+	defaultSSERetry    = 3 * time.Second
+	maxSSERetry        = 30 * time.Second
+	maxSSEReconnects   = 5
+	maxSSELineSize     = 1 << 20
+	initialSSELineSize = 4 << 10
+)
+
+// ErrStopStream is returned by a stream handler to stop consuming the stream without error.
+var ErrStopStream = errors.New("stop stream")
+
+// ErrNotEventStream is returned by WithSSE when the response is not a text/event-stream.
+var ErrNotEventStream = errors.New("response is not an event stream")
+
+// Event is a Server-Sent Event.
+type Event struct {
+	// ID is the last event ID, sent back in Last-Event-ID on reconnection.
+	ID string
+	// Event is the event type, "message" by default.
+	Event string
+	// Data is the event payload, lines are joined with "\n".
+	Data string
+	// Retry is the reconnection delay requested by the server, zero when unset.
+	Retry time.Duration
+}
+
+// EventHandler handles a Server-Sent Event.
+type EventHandler func(e Event) error
+
+// WithSSE consumes a text/event-stream response, calling handler for every event.
+// When the stream ends, the request is built again, running the pre-request
+// handlers, and sent with the Last-Event-ID header after the retry delay requested
+// by the server, 3 seconds by default. A reconnection that cannot be sent is
+// retried with a doubling delay, up to 30 seconds, and the stream fails after 5
+// of them in a row; a request that cannot be built again, such as one with a body
+// that cannot be replayed, fails the stream right away.
+//
+// The stream is consumed until ctx is done, the server answers 204 No Content, or
+// handler returns an error; ErrStopStream stops the stream without error. A
+// response with another status than 200 OK fails with an *HTTPError, one with
+// another Content-Type with ErrNotEventStream, and a stream that cannot be read,
+// such as with a line longer than 1 MiB, fails with the read error.
+func WithSSE(handler EventHandler) Option {
+	return func(params *Params) {
+		state := &eventStream{retry: defaultSSERetry}
+
+		WithPreRequestHandler(
+			AcceptHandler,
+			func(_ context.Context, req *http.Request) error {
+				req.Header.Set("Accept", EventStreamContentType)
+				req.Header.Set("Cache-Control", "no-cache")
+				if state.lastID != "" {
+					req.Header.Set("Last-Event-ID", state.lastID)
+				}
+				return nil
+			},
+			Priority(PriorityBody),
+		).Apply(params)
+
+		WithPostRequestHandler(
+			SSEHandler,
+			func(ctx context.Context, req *http.Request, res *http.Response) error {
+				return consumeEvents(ctx, params, state, req, res, handler)
+			},
+			Priority(PriorityBody),
+		).Apply(params)
+	}
+}
+
+// WithNDJSONStream decodes a line-delimited JSON response incrementally, calling fn
+// for every value until the end of the body, ctx is done or fn returns an error;
+// ErrStopStream stops the stream without error.
+func WithNDJSONStream[T any](fn func(T) error) Option {
+	return func(params *Params) {
+		WithPreRequestHandler(
+			AcceptHandler,
+			func(_ context.Context, req *http.Request) error {
+				req.Header.Set("Accept", NDJSONContentType)
+				return nil
+			},
+			Priority(PriorityBody),
+		).Apply(params)
+
+		WithPostRequestHandler(
+			NDJSONStreamHandler,
+			func(ctx context.Context, _ *http.Request, res *http.Response) error {
+				dec := json.NewDecoder(res.Body)
+
+				for {
+					if err := ctx.Err(); err != nil {
+						return err
+					}
+
+					var v T
+					err := dec.Decode(&v)
+					switch {
+					case errors.Is(err, io.EOF):
+						return nil
+					case err != nil && ctx.Err() != nil:
+						return ctx.Err()
+					case err != nil:
+						return err
+					}
+
+					if err = fn(v); err != nil {
+						return ignoreStop(err)
+					}
+				}
+			},
+			Priority(PriorityBody),
+		).Apply(params)
+	}
+}
+
+// consumeEvents reads the events of res and reconnects until the stream is stopped.
+// The bodies of the reconnections replace the one of res, and the last one is
+// closed on return.
+func consumeEvents(
+	ctx context.Context,
+	params *Params,
+	state *eventStream,
+	req *http.Request,
+	res *http.Response,
+	handler EventHandler,
+) error {
+	body := res.Body
+	defer func() {
+		if res.Body != body && res.Body != nil {
+			_ = res.Body.Close()
+		}
+	}()
+
+	for {
+		if err := checkEventStream(req, res); err != nil {
+			return ignoreStop(err)
+		}
+
+		if err := state.read(res.Body, handler); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return ignoreStop(err)
+		}
+
+		nextReq, next, err := reconnect(ctx, params, state.retry)
+		if err != nil {
+			return err
+		}
+
+		if res.Body != nil {
+			_ = res.Body.Close()
+		}
+		req, *res = nextReq, *next
+	}
+}
+
+// checkEventStream checks the status and the Content-Type of an event stream
+// response. It returns ErrStopStream on 204 No Content.
+func checkEventStream(req *http.Request, res *http.Response) error {
+	switch {
+	case res.StatusCode == http.StatusNoContent:
+		return ErrStopStream
+	case res.StatusCode != http.StatusOK:
+		return &HTTPError{StatusCode: res.StatusCode, Header: res.Header, Method: req.Method, URL: req.URL.String()}
+	}
+
+	mediaType, _, err := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if err != nil || !strings.EqualFold(mediaType, EventStreamContentType) {
+		return fmt.Errorf("%w: %q", ErrNotEventStream, res.Header.Get("Content-Type"))
+	}
+
+	return nil
+}
+
+// reconnect builds the request again and sends it after delay. The sending
+// failures are retried with a doubling delay, while the errors building the
+// request are returned right away.
+func reconnect(ctx context.Context, params *Params, delay time.Duration) (*http.Request, *http.Response, error) {
+	var (
+		req *http.Request
+		res *http.Response
+		err error
+	)
+
+	for range maxSSEReconnects {
+		if err = sleep(ctx, delay); err != nil {
+			return nil, nil, err
+		}
+
+		req, err = params.newRequest(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		res, err = params.Client.Do(req) //nolint:bodyclose // it is closed by consumeEvents
+		if err == nil {
+			return req, res, nil
+		}
+
+		if ctx.Err() != nil {
+			return nil, nil, ctx.Err()
+		}
+
+		delay = min(2*delay, maxSSERetry)
+	}
+
+	return nil, nil, fmt.Errorf("cannot reconnect event stream: %w", err)
+}
+
+// eventStream holds the state of an event stream across reconnections.
+type eventStream struct {
+	lastID string
+	retry  time.Duration
+}
+
+// read parses the events of r until its end. It returns the errors returned by
+// handler and the read errors.
+func (s *eventStream) read(r io.Reader, handler EventHandler) error {
+	if r == nil {
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, initialSSELineSize), maxSSELineSize)
+
+	var (
+		data      strings.Builder
+		event     Event
+		extraData bool
+	)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if line == "" {
+			if !extraData {
+				event = Event{}
+				continue
+			}
+
+			event.ID = s.lastID
+			event.Data = strings.TrimSuffix(data.String(), "\n")
+			if event.Event == "" {
+				event.Event = "message"
+			}
+
+			if err := handler(event); err != nil {
+				return err
+			}
+
+			data.Reset()
+			event, extraData = Event{}, false
+			continue
+		}
+
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+
+		switch field {
+		case "event":
+			event.Event = value
+		case "data":
+			data.WriteString(value)
+			data.WriteByte('\n')
+			extraData = true
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				s.lastID = value
+			}
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms >= 0 {
+				s.retry = time.Duration(ms) * time.Millisecond
+				event.Retry = s.retry
+			}
+		}
+	}
+
+	return scanner.Err()
+}
+
+// ignoreStop returns nil for ErrStopStream.
+func ignoreStop(err error) error {
+	if errors.Is(err, ErrStopStream) {
+		return nil
+	}
+
+	return err
+}
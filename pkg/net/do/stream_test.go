@@ -0,0 +1,231 @@
+package do_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/merlindorin/go-shared/pkg/must"
+	"github.com/merlindorin/go-shared/pkg/net/do"
+)
+
+func TestSSE(t *testing.T) {
+	t.Run("should parse events and reconnect with the last event id", func(t *testing.T) {
+		var connections int
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			connections++
+			assert.Equal(t, do.EventStreamContentType, r.Header.Get("Accept"))
+			w.Header().Set("Content-Type", do.EventStreamContentType)
+
+			switch connections {
+			case 1:
+				assert.Empty(t, r.Header.Get("Last-Event-ID"))
+				_, _ = fmt.Fprint(w, "retry: 1\n: comment\n\nid: 1\ndata: hello\n\n")
+				_, _ = fmt.Fprint(w, "event: update\nid: 2\ndata: multi\ndata: line\n\n")
+			default:
+				assert.Equal(t, "2", r.Header.Get("Last-Event-ID"))
+				_, _ = fmt.Fprint(w, "id: 3\ndata: bye\n\n")
+			}
+		}))
+		defer srv.Close()
+
+		var events []do.Event
+		err := do.Do(
+			context.TODO(),
+			must.Get(url.Parse(srv.URL)),
+			do.WithSSE(func(e do.Event) error {
+				events = append(events, e)
+				if e.ID == "3" {
+					return do.ErrStopStream
+				}
+				return nil
+			}),
+		)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, connections)
+		assert.Equal(t, []string{"hello", "multi\nline", "bye"}, []string{events[0].Data, events[1].Data, events[2].Data})
+		assert.Equal(t, []string{"message", "update", "message"}, []string{events[0].Event, events[1].Event, events[2].Event})
+		assert.Equal(t, "2", events[1].ID)
+	})
+
+	t.Run("should stop reconnecting on 204 No Content", func(t *testing.T) {
+		var connections int
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			connections++
+			if connections > 1 {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			w.Header().Set("Content-Type", do.EventStreamContentType)
+			_, _ = fmt.Fprint(w, "retry: 1\ndata: once\n\n")
+		}))
+		defer srv.Close()
+
+		err := do.Do(context.TODO(), must.Get(url.Parse(srv.URL)), do.WithSSE(func(_ do.Event) error { return nil }))
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, connections)
+	})
+
+	t.Run("should fail on an error status without reconnecting", func(t *testing.T) {
+		var connections int
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			connections++
+			http.Error(w, "data: not found", http.StatusNotFound)
+		}))
+		defer srv.Close()
+
+		err := do.Do(context.TODO(), must.Get(url.Parse(srv.URL)), do.WithSSE(func(_ do.Event) error { return nil }))
+
+		var httpErr *do.HTTPError
+		assert.ErrorAs(t, err, &httpErr)
+		assert.Equal(t, http.StatusNotFound, httpErr.StatusCode)
+		assert.Equal(t, 1, connections)
+	})
+
+	t.Run("should fail on another content type", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = fmt.Fprint(w, "{}")
+		}))
+		defer srv.Close()
+
+		err := do.Do(context.TODO(), must.Get(url.Parse(srv.URL)), do.WithSSE(func(_ do.Event) error { return nil }))
+
+		assert.ErrorIs(t, err, do.ErrNotEventStream)
+	})
+
+	t.Run("should run the pre-request handlers on reconnection", func(t *testing.T) {
+		var tokens []string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tokens = append(tokens, r.Header.Get("Authorization"))
+			if len(tokens) > 1 {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			w.Header().Set("Content-Type", do.EventStreamContentType)
+			_, _ = fmt.Fprint(w, "retry: 1\ndata: once\n\n")
+		}))
+		defer srv.Close()
+
+		var refreshes int
+		err := do.Do(context.TODO(), must.Get(url.Parse(srv.URL)),
+			do.WithPreRequestHandler("token", func(_ context.Context, req *http.Request) error {
+				refreshes++
+				req.Header.Set("Authorization", fmt.Sprintf("Bearer %d", refreshes))
+				return nil
+			}),
+			do.WithSSE(func(_ do.Event) error { return nil }),
+		)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Bearer 1", "Bearer 2"}, tokens)
+	})
+
+	t.Run("should fail when the request cannot be built again", func(t *testing.T) {
+		var connections int
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			connections++
+			w.Header().Set("Content-Type", do.EventStreamContentType)
+			_, _ = fmt.Fprint(w, "retry: 1\ndata: once\n\n")
+		}))
+		defer srv.Close()
+
+		err := do.Do(context.TODO(), must.Get(url.Parse(srv.URL)),
+			do.WithMethod(http.MethodPost),
+			do.WithBody(io.MultiReader(strings.NewReader("query"))),
+			do.WithSSE(func(_ do.Event) error { return nil }),
+		)
+
+		assert.ErrorIs(t, err, do.ErrBodyNotReplayable)
+		assert.Equal(t, 1, connections)
+	})
+
+	t.Run("should give up reconnecting after consecutive failures", func(t *testing.T) {
+		wantErr := fmt.Errorf("connection refused")
+
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {do.EventStreamContentType}},
+			Body:       io.NopCloser(strings.NewReader("retry: 1\ndata: once\n\n")),
+		}, nil).Once()
+		mockClient.EXPECT().Do(mock.Anything).Return(nil, wantErr).Times(5)
+
+		err := do.Do(context.TODO(), &url.URL{},
+			do.WithClient(mockClient),
+			do.WithSSE(func(_ do.Event) error { return nil }),
+		)
+
+		assert.ErrorIs(t, err, wantErr)
+	})
+
+	t.Run("should fail on lines that are too long", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", do.EventStreamContentType)
+			_, _ = fmt.Fprint(w, "data: "+strings.Repeat("x", 2<<20)+"\n\n")
+		}))
+		defer srv.Close()
+
+		err := do.Do(context.TODO(), must.Get(url.Parse(srv.URL)), do.WithSSE(func(_ do.Event) error { return nil }))
+
+		assert.ErrorIs(t, err, bufio.ErrTooLong)
+	})
+}
+
+func TestNDJSONStream(t *testing.T) {
+	t.Run("should decode values incrementally", func(t *testing.T) {
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).Return(&http.Response{
+			Body: io.NopCloser(strings.NewReader("{\"name\":\"a\"}\n{\"name\":\"b\"}\n{\"name\":\"c\"}\n")),
+		}, nil).Once()
+
+		var got []string
+		err := do.Do(
+			context.TODO(),
+			&url.URL{},
+			do.WithClient(mockClient),
+			do.WithNDJSONStream(func(i item) error {
+				got = append(got, i.Name)
+				if i.Name == "b" {
+					return do.ErrStopStream
+				}
+				return nil
+			}),
+		)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a", "b"}, got)
+	})
+
+	t.Run("should stop when the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.TODO())
+
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).Return(&http.Response{
+			Body: io.NopCloser(strings.NewReader("{\"name\":\"a\"}\n{\"name\":\"b\"}\n")),
+		}, nil).Once()
+
+		err := do.Do(
+			ctx,
+			&url.URL{},
+			do.WithClient(mockClient),
+			do.WithNDJSONStream(func(_ item) error {
+				cancel()
+				return nil
+			}),
+		)
+
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
@@ -0,0 +1,217 @@
+package do
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// Trace is the timing breakdown of a request.
+type Trace struct {
+	// DNS is the duration of the DNS lookup.
+	DNS time.Duration
+	// Connect is the duration of the TCP connection.
+	Connect time.Duration
+	// TLSHandshake is the duration of the TLS handshake.
+	TLSHandshake time.Duration
+	// TimeToFirstByte is the duration until the first byte of the response.
+	TimeToFirstByte time.Duration
+	// Total is the duration until the response body is read to the end or closed.
+	Total time.Duration
+	// Reused reports whether the connection was reused from a previous request.
+	Reused bool
+}
+
+// fields returns the trace as zap fields.
+func (t Trace) fields() []zap.Field {
+	return []zap.Field{
+		zap.Duration("dns", t.DNS),
+		zap.Duration("connect", t.Connect),
+		zap.Duration("tlsHandshake", t.TLSHandshake),
+		zap.Duration("timeToFirstByte", t.TimeToFirstByte),
+		zap.Duration("total", t.Total),
+		zap.Bool("reused", t.Reused),
+	}
+}
+
+// TraceObserver receives the Trace of every traced request.
+type TraceObserver interface {
+	ObserveTrace(req *http.Request, trace Trace)
+}
+
+// TraceObserverFunc is an adapter to allow the use of ordinary functions as a TraceObserver.
+type TraceObserverFunc func(req *http.Request, trace Trace)
+
+// ObserveTrace calls f(req, trace).
+func (f TraceObserverFunc) ObserveTrace(req *http.Request, trace Trace) {
+	f(req, trace)
+}
+
+// WithTrace records the timing breakdown of every attempt of the request with
+// net/http/httptrace: DNS lookup, connection, TLS handshake, time to first byte
+// and total time, along with whether the connection was reused. The trace is
+// logged at debug level once the response body is read to the end or closed and
+// given to the observers, such as TraceMetrics.
+func WithTrace(observers ...TraceObserver) Option {
+	return func(params *Params) {
+		WithClientDecorator(func(next HTTPClientDoer) HTTPClientDoer {
+			return &tracingClient{next: next, params: params, observers: observers}
+		}).Apply(params)
+	}
+}
+
+// tracingClient is an HTTPClientDoer tracing requests.
+type tracingClient struct {
+	next      HTTPClientDoer
+	params    *Params
+	observers []TraceObserver
+}
+
+// Do sends the request with a client trace.
+func (c *tracingClient) Do(req *http.Request) (*http.Response, error) {
+	t := &tracer{now: c.params.now}
+	t.start = t.now()
+
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), t.clientTrace()))
+
+	res, err := c.next.Do(req)
+	if err != nil || res.Body == nil {
+		c.done(req, t)
+		return res, err
+	}
+
+	res.Body = NewCountingBody(res.Body, func(int64) { c.done(req, t) })
+
+	return res, nil
+}
+
+// done reports the trace of a finished request.
+func (c *tracingClient) done(req *http.Request, t *tracer) {
+	trace := t.trace()
+
+	c.params.Logger.Debug("requestTrace", append(trace.fields(), zap.String("host", req.URL.Host))...)
+
+	for _, observer := range c.observers {
+		observer.ObserveTrace(req, trace)
+	}
+}
+
+// tracer collects the events of a client trace.
+type tracer struct {
+	now func() time.Time
+
+	mu                            sync.Mutex
+	start, dnsStart, connectStart time.Time
+	tlsStart, firstByte           time.Time
+	dns, connect, tlsHandshake    time.Duration
+	reused                        bool
+}
+
+// clientTrace returns the httptrace hooks feeding the tracer.
+func (t *tracer) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { t.mark(&t.dnsStart) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { t.measure(&t.dns, &t.dnsStart) },
+		ConnectStart: func(string, string) {
+			t.mark(&t.connectStart)
+		},
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil {
+				t.measure(&t.connect, &t.connectStart)
+			}
+		},
+		TLSHandshakeStart: func() { t.mark(&t.tlsStart) },
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.measure(&t.tlsHandshake, &t.tlsStart)
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			t.reused = info.Reused
+			t.mu.Unlock()
+		},
+		GotFirstResponseByte: func() { t.mark(&t.firstByte) },
+	}
+}
+
+// mark records the current time in at.
+func (t *tracer) mark(at *time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	*at = t.now()
+}
+
+// measure records in d the time elapsed since start.
+func (t *tracer) measure(d *time.Duration, start *time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	*d = t.now().Sub(*start)
+}
+
+// trace returns the Trace of the request, which ends now.
+func (t *tracer) trace() Trace {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	trace := Trace{
+		DNS:          t.dns,
+		Connect:      t.connect,
+		TLSHandshake: t.tlsHandshake,
+		Total:        t.now().Sub(t.start),
+		Reused:       t.reused,
+	}
+
+	if !t.firstByte.IsZero() {
+		trace.TimeToFirstByte = t.firstByte.Sub(t.start)
+	}
+
+	return trace
+}
+
+// TraceMetrics is a TraceObserver exposing the traces as Prometheus histograms,
+// labelled by host and phase. It must be registered to be exposed.
+type TraceMetrics struct {
+	durations *prometheus.HistogramVec
+}
+
+// NewTraceMetrics creates a TraceMetrics.
+func NewTraceMetrics() *TraceMetrics {
+	return &TraceMetrics{
+		durations: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_client_trace_duration_seconds",
+			Help:    "Duration of the phases of HTTP client requests.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"host", "phase"}),
+	}
+}
+
+// ObserveTrace records the durations of the phases of the trace.
+func (m *TraceMetrics) ObserveTrace(req *http.Request, trace Trace) {
+	if !trace.Reused {
+		m.durations.WithLabelValues(req.URL.Host, "dns").Observe(trace.DNS.Seconds())
+		m.durations.WithLabelValues(req.URL.Host, "connect").Observe(trace.Connect.Seconds())
+		m.durations.WithLabelValues(req.URL.Host, "tls_handshake").Observe(trace.TLSHandshake.Seconds())
+	}
+
+	if trace.TimeToFirstByte > 0 {
+		m.durations.WithLabelValues(req.URL.Host, "time_to_first_byte").Observe(trace.TimeToFirstByte.Seconds())
+	}
+
+	m.durations.WithLabelValues(req.URL.Host, "total").Observe(trace.Total.Seconds())
+}
+
+// Describe returns all descriptions of the collector.
+func (m *TraceMetrics) Describe(ch chan<- *prometheus.Desc) {
+	m.durations.Describe(ch)
+}
+
+// Collect returns the current state of all metrics of the collector.
+func (m *TraceMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.durations.Collect(ch)
+}
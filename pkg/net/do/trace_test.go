@@ -0,0 +1,78 @@
+package do_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/merlindorin/go-shared/pkg/must"
+	"github.com/merlindorin/go-shared/pkg/net/do"
+)
+
+func TestWithTrace(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	u := must.Get(url.Parse(server.URL))
+	client := &http.Client{Transport: &http.Transport{}}
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	metrics := do.NewTraceMetrics()
+
+	var traces []do.Trace
+	recorder := do.TraceObserverFunc(func(_ *http.Request, trace do.Trace) {
+		traces = append(traces, trace)
+	})
+
+	for range 2 {
+		err := do.Do(t.Context(), u,
+			do.WithClient(client),
+			do.WithLogger(zap.New(core)),
+			do.WithTrace(recorder, metrics),
+		)
+		assert.NoError(t, err)
+	}
+
+	assert.Len(t, traces, 2)
+	assert.False(t, traces[0].Reused)
+	assert.Positive(t, traces[0].Connect)
+	assert.Positive(t, traces[0].TimeToFirstByte)
+	assert.GreaterOrEqual(t, traces[0].Total, traces[0].TimeToFirstByte)
+	assert.True(t, traces[1].Reused)
+
+	entries := logs.FilterMessage("requestTrace").All()
+	assert.Len(t, entries, 2)
+	assert.Equal(t, u.Host, entries[0].ContextMap()["host"])
+	assert.Equal(t, true, entries[1].ContextMap()["reused"])
+
+	// dns, connect, tls_handshake, time_to_first_byte and total for the single host.
+	assert.Equal(t, 5, testutil.CollectAndCount(metrics))
+}
+
+func TestWithTraceWithUnmarshalBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"name":"item"}`))
+	}))
+	defer server.Close()
+
+	core, logs := observer.New(zapcore.DebugLevel)
+
+	var got item
+	err := do.Do(t.Context(), must.Get(url.Parse(server.URL)),
+		do.WithLogger(zap.New(core)),
+		do.WithTrace(),
+		do.WithUnmarshalBody(&got),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, item{Name: "item"}, got)
+	assert.Len(t, logs.FilterMessage("requestTrace").All(), 1)
+}
@@ -0,0 +1,123 @@
+package rest
+
+import (
+	"context"
+	"hash/fnv"
+	"math/rand/v2"
+	"sync/atomic"
+
+	"github.com/merlindorin/go-shared/pkg/net/do"
+)
+
+// Policy picks the endpoint a request is sent to.
+type Policy interface {
+	// Pick returns one of the given endpoints, which are never empty.
+	Pick(ctx context.Context, endpoints []*Endpoint) *Endpoint
+}
+
+// PolicyFunc is an adapter to allow the use of ordinary functions as a Policy.
+type PolicyFunc func(ctx context.Context, endpoints []*Endpoint) *Endpoint
+
+// Pick calls f(ctx, endpoints).
+func (f PolicyFunc) Pick(ctx context.Context, endpoints []*Endpoint) *Endpoint {
+	return f(ctx, endpoints)
+}
+
+// RoundRobin returns a Policy picking the endpoints in turn.
+func RoundRobin() Policy {
+	var next atomic.Uint64
+
+	return PolicyFunc(func(_ context.Context, endpoints []*Endpoint) *Endpoint {
+		return endpoints[(next.Add(1)-1)%uint64(len(endpoints))]
+	})
+}
+
+// Random returns a Policy picking an endpoint at random.
+func Random() Policy {
+	return PolicyFunc(func(_ context.Context, endpoints []*Endpoint) *Endpoint {
+		return endpoints[rand.IntN(len(endpoints))] //nolint:gosec // load balancing does not need a secure random
+	})
+}
+
+// LeastInFlight returns a Policy picking the endpoint with the fewest requests in flight.
+func LeastInFlight() Policy {
+	return PolicyFunc(func(_ context.Context, endpoints []*Endpoint) *Endpoint {
+		least := endpoints[0]
+		for _, e := range endpoints[1:] {
+			if e.InFlight() < least.InFlight() {
+				least = e
+			}
+		}
+
+		return least
+	})
+}
+
+// balanceKey is the context key of the request key used by ConsistentHash.
+type balanceKey struct{}
+
+// WithBalanceKey returns a copy of ctx carrying the key used by ConsistentHash
+// to pick the endpoint of the requests sent with it.
+func WithBalanceKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, balanceKey{}, key)
+}
+
+// ConsistentHash returns a Policy sending the requests with the same key, set
+// with WithBalanceKey, to the same endpoint. It uses rendezvous hashing so that
+// adding or removing an endpoint only moves the keys of that endpoint. Requests
+// without key are sent to a random endpoint.
+func ConsistentHash() Policy {
+	random := Random()
+
+	return PolicyFunc(func(ctx context.Context, endpoints []*Endpoint) *Endpoint {
+		key, ok := ctx.Value(balanceKey{}).(string)
+		if !ok {
+			return random.Pick(ctx, endpoints)
+		}
+
+		var (
+			best      *Endpoint
+			bestScore uint64
+		)
+
+		for _, e := range endpoints {
+			h := fnv.New64a()
+			_, _ = h.Write([]byte(e.url.String()))
+			_, _ = h.Write([]byte{0})
+			_, _ = h.Write([]byte(key))
+
+			if score := h.Sum64(); best == nil || score > bestScore {
+				best, bestScore = e, score
+			}
+		}
+
+		return best
+	})
+}
+
+// NewBalanced creates a new Rest client spreading its requests over the
+// endpoints of pool according to policy. The pool can be updated at runtime,
+// for instance by a discovery loop, without rebuilding the client. Endpoints
+// failing consecutively are ejected from the balancing for a while. The
+// attempts of a retried request are sent to the same endpoint.
+//
+// The options are the same as NewRest. The requests of the client and its
+// children share the pool.
+func NewBalanced(pool *Pool, policy Policy, options ...do.Option) *Rest {
+	send := do.D(func(ctx context.Context, options ...do.Option) error {
+		endpoints := pool.Available()
+		if len(endpoints) == 0 {
+			return ErrNoEndpoint
+		}
+
+		e := policy.Pick(ctx, endpoints)
+
+		track := do.WithClientDecorator(func(next do.HTTPClientDoer) do.HTTPClientDoer {
+			return &endpointClient{next: next, pool: pool, endpoint: e}
+		})
+
+		return do.Do(ctx, e.URL(), append(options, track)...)
+	})
+
+	return newRest(send, append([]do.Option{do.WithStatusCheck()}, options...))
+}
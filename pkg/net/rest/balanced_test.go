@@ -0,0 +1,152 @@
+package rest_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/merlindorin/go-shared/pkg/must"
+	"github.com/merlindorin/go-shared/pkg/net/do"
+	"github.com/merlindorin/go-shared/pkg/net/rest"
+)
+
+// hostRecorder returns a mock client answering with the given status and the list of hosts it received.
+func hostRecorder(t *testing.T, status func(host string) int) (*do.MockHttpClientDoer, *[]string) {
+	t.Helper()
+
+	var hosts []string
+
+	mockClient := do.NewMockHttpClientDoer(t)
+	mockClient.EXPECT().Do(mock.Anything).RunAndReturn(func(req *http.Request) (*http.Response, error) {
+		hosts = append(hosts, req.URL.Host)
+		return &http.Response{StatusCode: status(req.URL.Host), Body: io.NopCloser(strings.NewReader(""))}, nil
+	}).Maybe()
+
+	return mockClient, &hosts
+}
+
+func ok(string) int { return http.StatusOK }
+
+func TestNewBalanced(t *testing.T) {
+	a := must.Get(url.Parse("http://a/api"))
+	b := must.Get(url.Parse("http://b/api"))
+
+	t.Run("should send requests in turn with round-robin", func(t *testing.T) {
+		mockClient, hosts := hostRecorder(t, ok)
+
+		r := rest.NewBalanced(rest.NewPool([]*url.URL{a, b}), rest.RoundRobin(), do.WithClient(mockClient))
+
+		for range 4 {
+			assert.NoError(t, r.GET(t.Context(), do.WithPath("/items")))
+		}
+
+		assert.Equal(t, []string{"a", "b", "a", "b"}, *hosts)
+	})
+
+	t.Run("should eject endpoints failing consecutively", func(t *testing.T) {
+		mockClient, hosts := hostRecorder(t, func(host string) int {
+			if host == "a" {
+				return http.StatusServiceUnavailable
+			}
+			return http.StatusOK
+		})
+
+		pool := rest.NewPool([]*url.URL{a, b}, rest.WithMaxFailures(1))
+		r := rest.NewBalanced(pool, rest.RoundRobin(), do.WithClient(mockClient))
+
+		assert.ErrorIs(t, r.GET(t.Context()), do.ErrServerError)
+		for range 3 {
+			assert.NoError(t, r.GET(t.Context()))
+		}
+
+		assert.Equal(t, []string{"a", "b", "b", "b"}, *hosts)
+		assert.True(t, pool.Ejected(pool.Endpoints()[0]))
+	})
+
+	t.Run("should send requests with the same key to the same endpoint", func(t *testing.T) {
+		mockClient, hosts := hostRecorder(t, ok)
+
+		pool := rest.NewPool([]*url.URL{a})
+		r := rest.NewBalanced(pool, rest.ConsistentHash(), do.WithClient(mockClient))
+
+		pool.Add(b)
+		pool.Add(must.Get(url.Parse("http://c/api")))
+
+		ctx := rest.WithBalanceKey(t.Context(), "device-42")
+		for range 3 {
+			assert.NoError(t, r.GET(ctx))
+		}
+
+		assert.Len(t, *hosts, 3)
+		assert.Equal(t, (*hosts)[0], (*hosts)[1])
+		assert.Equal(t, (*hosts)[0], (*hosts)[2])
+	})
+
+	t.Run("should pick the endpoint with the fewest requests in flight", func(t *testing.T) {
+		release := make(chan struct{})
+
+		var hosts []string
+
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).RunAndReturn(func(req *http.Request) (*http.Response, error) {
+			if req.URL.Host == "a" {
+				<-release
+			} else {
+				hosts = append(hosts, req.URL.Host)
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(""))}, nil
+		})
+
+		pool := rest.NewPool([]*url.URL{a, b})
+		r := rest.NewBalanced(pool, rest.LeastInFlight(), do.WithClient(mockClient))
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			assert.NoError(t, r.GET(t.Context()))
+		}()
+
+		assert.Eventually(t, func() bool { return pool.Endpoints()[0].InFlight() == 1 }, time.Second, time.Millisecond)
+
+		for range 2 {
+			assert.NoError(t, r.GET(t.Context()))
+		}
+
+		close(release)
+		<-done
+
+		assert.Equal(t, []string{"b", "b"}, hosts)
+		assert.Zero(t, pool.Endpoints()[0].InFlight())
+	})
+
+	t.Run("should release the endpoint of decoded responses", func(t *testing.T) {
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).RunAndReturn(func(*http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{"name":"a"}`))}, nil
+		}).Twice()
+
+		pool := rest.NewPool([]*url.URL{a})
+		r := rest.NewBalanced(pool, rest.LeastInFlight(), do.WithClient(mockClient))
+
+		res := must.Get(rest.Get[map[string]string](t.Context(), r))
+		assert.Equal(t, map[string]string{"name": "a"}, res.Body)
+		assert.Zero(t, pool.Endpoints()[0].InFlight())
+
+		_, err := rest.Post[map[string]string, map[string]string](t.Context(), r, map[string]string{"name": "b"})
+		assert.NoError(t, err)
+		assert.Zero(t, pool.Endpoints()[0].InFlight())
+	})
+
+	t.Run("should fail without endpoint", func(t *testing.T) {
+		r := rest.NewBalanced(rest.NewPool(nil), rest.Random())
+
+		assert.ErrorIs(t, r.GET(context.Background()), rest.ErrNoEndpoint)
+	})
+}
@@ -0,0 +1,236 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/merlindorin/go-shared/pkg/discover"
+	"github.com/merlindorin/go-shared/pkg/net/do"
+	"github.com/merlindorin/go-shared/pkg/net/rest"
+)
+
+const (
+	defaultInterval           = 30 * time.Second
+	defaultTimeout            = 5 * time.Second
+	defaultHealthCheckTimeout = 5 * time.Second
+	defaultTTLRatio           = 3
+)
+
+// MapFunc maps a discovered entry to the base URL of an endpoint. Entries for
+// which it returns false are ignored.
+type MapFunc func(entry interface{}) (*url.URL, bool)
+
+// HealthCheck reports whether the endpoint of the given base URL is healthy.
+type HealthCheck func(ctx context.Context, u *url.URL) error
+
+// HTTPHealthCheck returns a HealthCheck sending a GET request to path on the
+// endpoint, which is healthy when it answers with a status code below 400.
+func HTTPHealthCheck(path string, options ...do.Option) HealthCheck {
+	return func(ctx context.Context, u *url.URL) error {
+		return do.Do(ctx, u, append([]do.Option{do.WithPath("%s", path), do.WithStatusCheck()}, options...)...)
+	}
+}
+
+// Pool is a rest.Pool whose endpoints are discovered by a discover.Resolverer.
+type Pool struct {
+	*rest.Pool
+
+	resolver           discover.Resolverer
+	mapURL             MapFunc
+	interval           time.Duration
+	timeout            time.Duration
+	ttl                time.Duration
+	healthCheck        HealthCheck
+	healthCheckTimeout time.Duration
+	poolOptions        []rest.PoolOption
+	logger             *zap.Logger
+	now                func() time.Time
+
+	mu    sync.Mutex
+	seen  map[string]time.Time
+	urls  map[string]*url.URL
+	ready map[string]bool
+}
+
+// NewPool creates an empty Pool filled by the entries of resolver mapped with
+// mapURL. The resolver must close the channel of discovered entries once done,
+// as the mdns and ssdp resolvers do. By default, discovery runs every 30
+// seconds for 5 seconds, endpoints not seen for three intervals are removed and
+// health checks time out after 5 seconds.
+func NewPool(resolver discover.Resolverer, mapURL MapFunc, opts ...Option) *Pool {
+	defaultOptions := []Option{
+		WithInterval(defaultInterval),
+		WithTimeout(defaultTimeout),
+		WithHealthCheckTimeout(defaultHealthCheckTimeout),
+		WithLogger(zap.NewNop()),
+		WithNow(time.Now),
+	}
+
+	p := &Pool{
+		resolver: resolver,
+		mapURL:   mapURL,
+		seen:     map[string]time.Time{},
+		urls:     map[string]*url.URL{},
+		ready:    map[string]bool{},
+	}
+
+	for _, opt := range append(defaultOptions, opts...) {
+		opt.apply(p)
+	}
+
+	if p.ttl == 0 {
+		p.ttl = defaultTTLRatio * p.interval
+	}
+
+	p.Pool = rest.NewPool(nil, p.poolOptions...)
+
+	return p
+}
+
+// Run refreshes the pool every interval until ctx is done.
+func (p *Pool) Run(ctx context.Context) error {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.Refresh(ctx); err != nil {
+			p.logger.Warn("cannot refresh discovered endpoints", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh runs a discovery round, then updates the endpoints of the pool.
+func (p *Pool) Refresh(ctx context.Context) error {
+	err := p.discover(ctx)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	p.expire()
+	p.check(ctx)
+	p.update()
+
+	return err
+}
+
+// discover records the endpoints seen during a discovery round.
+func (p *Pool) discover(ctx context.Context) error {
+	entries := make(chan interface{})
+	errc := make(chan error, 1)
+
+	go func() {
+		errc <- discover.NewDiscover(p.resolver, discover.WithTimeout(p.timeout)).Discover(ctx, entries)
+	}()
+
+	for entry := range entries {
+		u, ok := p.mapURL(entry)
+		if !ok {
+			continue
+		}
+
+		p.mu.Lock()
+		p.seen[u.String()] = p.now()
+		p.urls[u.String()] = u
+		if _, known := p.ready[u.String()]; !known {
+			p.ready[u.String()] = p.healthCheck == nil
+		}
+		p.mu.Unlock()
+	}
+
+	if err := <-errc; err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	return nil
+}
+
+// expire forgets the endpoints not seen within the TTL.
+func (p *Pool) expire() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	for key, seen := range p.seen {
+		if now.Sub(seen) > p.ttl {
+			delete(p.seen, key)
+			delete(p.urls, key)
+			delete(p.ready, key)
+		}
+	}
+}
+
+// check runs the health check of every known endpoint.
+func (p *Pool) check(ctx context.Context) {
+	if p.healthCheck == nil {
+		return
+	}
+
+	p.mu.Lock()
+	urls := maps.Clone(p.urls)
+	p.mu.Unlock()
+
+	var g errgroup.Group
+
+	for key, u := range urls {
+		g.Go(func() error {
+			err := p.checkEndpoint(ctx, u)
+			if err != nil {
+				p.logger.Debug("discovered endpoint is unhealthy", zap.Stringer("url", u), zap.Error(err))
+			}
+
+			p.mu.Lock()
+			if _, ok := p.ready[key]; ok {
+				p.ready[key] = err == nil
+			}
+			p.mu.Unlock()
+
+			return nil
+		})
+	}
+
+	_ = g.Wait()
+}
+
+// checkEndpoint runs the health check of u within the health check timeout.
+func (p *Pool) checkEndpoint(ctx context.Context, u *url.URL) error {
+	if p.healthCheckTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.healthCheckTimeout)
+		defer cancel()
+	}
+
+	return p.healthCheck(ctx, u)
+}
+
+// update sets the known healthy endpoints in the pool.
+func (p *Pool) update() {
+	p.mu.Lock()
+	urls := make([]*url.URL, 0, len(p.urls))
+	for _, key := range slices.Sorted(maps.Keys(p.urls)) {
+		if p.ready[key] {
+			urls = append(urls, p.urls[key])
+		}
+	}
+	p.mu.Unlock()
+
+	before := len(p.Endpoints())
+	p.Set(urls...)
+
+	if before != len(urls) {
+		p.logger.Info("discovered endpoints changed", zap.Int("before", before), zap.Int("after", len(urls)))
+	}
+}
@@ -0,0 +1,118 @@
+package discovery_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/merlindorin/go-shared/pkg/net/rest/discovery"
+)
+
+// resolver sends its entries then closes the channel, as the mdns and ssdp resolvers do.
+type resolver struct {
+	entries []interface{}
+}
+
+func (r *resolver) Resolve(ctx context.Context, discovered chan<- interface{}) error {
+	defer close(discovered)
+
+	for _, entry := range r.entries {
+		select {
+		case discovered <- entry:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+func toURL(entry interface{}) (*url.URL, bool) {
+	host, ok := entry.(string)
+	if !ok {
+		return nil, false
+	}
+
+	return &url.URL{Scheme: "http", Host: host}, true
+}
+
+func hosts(p *discovery.Pool) []string {
+	var hosts []string
+	for _, u := range p.URLs() {
+		hosts = append(hosts, u.Host)
+	}
+
+	return hosts
+}
+
+func TestPool(t *testing.T) {
+	t.Run("should add seen endpoints and remove expired ones", func(t *testing.T) {
+		now := time.Now()
+		r := &resolver{entries: []interface{}{"b:80", "a:80", 42}}
+
+		p := discovery.NewPool(r, toURL,
+			discovery.WithTTL(time.Minute),
+			discovery.WithNow(func() time.Time { return now }),
+		)
+
+		assert.NoError(t, p.Refresh(t.Context()))
+		assert.Equal(t, []string{"a:80", "b:80"}, hosts(p))
+
+		r.entries = []interface{}{"a:80"}
+		now = now.Add(2 * time.Minute)
+
+		assert.NoError(t, p.Refresh(t.Context()))
+		assert.Equal(t, []string{"a:80"}, hosts(p))
+	})
+
+	t.Run("should remove endpoints failing the health check", func(t *testing.T) {
+		healthy := map[string]bool{"a:80": true}
+		r := &resolver{entries: []interface{}{"a:80", "b:80"}}
+
+		p := discovery.NewPool(r, toURL, discovery.WithHealthCheck(func(_ context.Context, u *url.URL) error {
+			if !healthy[u.Host] {
+				return errors.New("unhealthy")
+			}
+			return nil
+		}))
+
+		assert.NoError(t, p.Refresh(t.Context()))
+		assert.Equal(t, []string{"a:80"}, hosts(p))
+
+		healthy["b:80"] = true
+
+		assert.NoError(t, p.Refresh(t.Context()))
+		assert.Equal(t, []string{"a:80", "b:80"}, hosts(p))
+	})
+
+	t.Run("should remove endpoints whose health check times out", func(t *testing.T) {
+		r := &resolver{entries: []interface{}{"a:80", "b:80"}}
+
+		p := discovery.NewPool(r, toURL,
+			discovery.WithHealthCheckTimeout(10*time.Millisecond),
+			discovery.WithHealthCheck(func(ctx context.Context, u *url.URL) error {
+				if u.Host == "b:80" {
+					<-ctx.Done()
+					return ctx.Err()
+				}
+				return nil
+			}),
+		)
+
+		assert.NoError(t, p.Refresh(t.Context()))
+		assert.Equal(t, []string{"a:80"}, hosts(p))
+	})
+
+	t.Run("should stop running when the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+
+		p := discovery.NewPool(&resolver{}, toURL)
+
+		assert.ErrorIs(t, p.Run(ctx), context.Canceled)
+	})
+}
@@ -0,0 +1,12 @@
+// Package discovery keeps a rest.Pool in sync with the services found by a
+// discover.Resolverer, such as the mdns and ssdp resolvers, so that a
+// discovered fleet is directly addressable with rest.NewBalanced.
+//
+// A Pool runs discovery rounds at a regular interval. Entries are mapped to
+// base URLs by a user function; endpoints are added when they are seen, and
+// removed when they were not seen for a while or fail their health check.
+//
+//	pool := discovery.NewPool(mdns.New(mdns.WithService("_api._tcp")), toURL)
+//	go pool.Run(ctx)
+//	client := rest.NewBalanced(pool.Pool, rest.RoundRobin())
+package discovery
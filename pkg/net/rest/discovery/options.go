@@ -0,0 +1,75 @@
+package discovery
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/merlindorin/go-shared/pkg/net/rest"
+)
+
+// Option represents a configuration setting that can be applied to a Pool.
+type Option func(p *Pool)
+
+// apply sets the given Option to the Pool.
+func (o Option) apply(p *Pool) {
+	o(p)
+}
+
+// WithInterval sets the period between two discovery rounds.
+func WithInterval(d time.Duration) Option {
+	return func(p *Pool) {
+		p.interval = d
+	}
+}
+
+// WithTimeout sets the duration of a discovery round.
+func WithTimeout(d time.Duration) Option {
+	return func(p *Pool) {
+		p.timeout = d
+	}
+}
+
+// WithTTL sets how long an endpoint stays in the pool after it was last seen.
+func WithTTL(d time.Duration) Option {
+	return func(p *Pool) {
+		p.ttl = d
+	}
+}
+
+// WithHealthCheck sets the health check run on every endpoint after each
+// discovery round. Endpoints failing it are removed until they pass it again.
+func WithHealthCheck(check HealthCheck) Option {
+	return func(p *Pool) {
+		p.healthCheck = check
+	}
+}
+
+// WithHealthCheckTimeout sets how long a health check may run before the
+// endpoint is considered unhealthy. Zero disables the timeout.
+func WithHealthCheckTimeout(d time.Duration) Option {
+	return func(p *Pool) {
+		p.healthCheckTimeout = d
+	}
+}
+
+// WithPoolOptions sets the options of the underlying rest.Pool.
+func WithPoolOptions(opts ...rest.PoolOption) Option {
+	return func(p *Pool) {
+		p.poolOptions = opts
+	}
+}
+
+// WithLogger sets the logger used to report the changes of the pool.
+func WithLogger(logger *zap.Logger) Option {
+	return func(p *Pool) {
+		p.logger = logger
+	}
+}
+
+// WithNow sets the clock of the Pool.
+func WithNow(now func() time.Time) Option {
+	return func(p *Pool) {
+		p.now = now
+	}
+}
@@ -7,10 +7,32 @@
 // Example usage:
 //
 //	baseURL, _ := url.Parse("https://api.example.com")
-//	client := rest.NewRest(baseURL, do.WithHeader("Authorization", "Bearer token"))
-//	response, err := client.GET(ctx, do.WithPath("/users"))
-//	if err != nil {
-//	    // handle error
+//	client := rest.NewRest(baseURL, do.WithExtraHeader("Authorization", "Bearer token"))
+//	var users []User
+//	err := client.GET(ctx, do.WithPath("/users"), do.WithUnmarshalBody(&users))
+//	if errors.Is(err, do.ErrNotFound) {
+//	    // handle a 404 response
 //	}
-//	// use response
+//	// use users
+//
+// Responses with a 4xx or 5xx status code are reported as a *do.HTTPError.
+//
+// The generic helpers Get, Post, Put, Patch, Delete and Do decode the response
+// body with the configured do.Codec and return it along with the response metadata captured by do.WithResponseInto:
+//
+//	res, err := rest.Get[[]User](ctx, client, do.WithPath("/users"))
+//
+// Paginate iterates over the items of every page of a listing endpoint, following
+// Link headers, cursors, offsets or page numbers depending on the Strategy:
+//
+//	for user, err := range rest.Paginate[User](ctx, client, rest.LinkHeader(rest.Items[User])) {
+//	    // use user
+//	}
+//
+// NewBalanced spreads the requests of a client over the endpoints of a Pool with
+// a round-robin, random, least-in-flight or consistent hashing Policy. The pool
+// can be updated at runtime and ejects endpoints failing consecutively:
+//
+//	pool := rest.NewPool([]*url.URL{replica1, replica2})
+//	client := rest.NewBalanced(pool, rest.RoundRobin())
 package rest
@@ -0,0 +1,359 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/merlindorin/go-shared/pkg/net/do"
+)
+
+// paginationURLHandler is the name of the pre-request handler sending a request to the next page URL.
+const paginationURLHandler = "rest_pagination_url"
+
+// ErrCrossOriginLink is returned when the next link of a page targets another
+// origin than the page, which would send the credentials of the client there.
+var ErrCrossOriginLink = errors.New("next link to another origin")
+
+// Position is the progress of a pagination.
+type Position struct {
+	// Pages is the number of pages received.
+	Pages int
+	// Items is the number of items received.
+	Items int
+}
+
+// Strategy describes how the pages of a listing endpoint are requested. Pages
+// are decoded into a P holding items of type T.
+type Strategy[P, T any] interface {
+	// Items returns the items of a page.
+	Items(page *Response[P]) []T
+	// Next returns the options requesting the page following page, which is
+	// nil for the first page, or false when page is the last one.
+	Next(page *Response[P], pos Position) ([]do.Option, bool)
+}
+
+// Items returns the page itself, for listing endpoints responding with a bare list of items.
+func Items[T any](page []T) []T {
+	return page
+}
+
+// Paginate returns an iterator over the items of every page of a listing
+// endpoint, requested with r and the given options according to strategy. The
+// iteration stops at the first error, which is yielded, and when ctx is done
+// between two pages.
+//
+//	strategy := rest.LinkHeader(rest.Items[User])
+//	for user, err := range rest.Paginate[User](ctx, client, strategy, do.WithPath("/users")) {
+//	    ...
+//	}
+func Paginate[T, P any](
+	ctx context.Context,
+	r Requester,
+	strategy Strategy[P, T],
+	options ...do.Option,
+) iter.Seq2[T, error] {
+	_, prefetch := strategy.(prefetcher)
+
+	return func(yield func(T, error) bool) {
+		var zero T
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		fetch := func(prev *Response[P], pos Position) (*Response[P], error) {
+			next, ok := strategy.Next(prev, pos)
+			if !ok {
+				return nil, nil
+			}
+
+			return Do[P](ctx, r, append(slices.Clip(options), next...)...)
+		}
+
+		var pos Position
+
+		page, err := fetch(nil, pos)
+
+		for page != nil || err != nil {
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+
+			items := strategy.Items(page)
+			pos.Pages++
+			pos.Items += len(items)
+
+			var prefetched chan *prefetchResult[P]
+			if prefetch {
+				prefetched = make(chan *prefetchResult[P], 1)
+				go func(page *Response[P], pos Position) {
+					next, err := fetch(page, pos)
+					prefetched <- &prefetchResult[P]{page: next, err: err}
+				}(page, pos)
+			}
+
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+
+			if err = ctx.Err(); err != nil {
+				yield(zero, err)
+				return
+			}
+
+			if prefetch {
+				result := <-prefetched
+				page, err = result.page, result.err
+			} else {
+				page, err = fetch(page, pos)
+			}
+		}
+	}
+}
+
+// prefetcher marks the strategies whose next page is requested while the items of the current one are consumed.
+type prefetcher interface {
+	prefetch()
+}
+
+// prefetchResult is the outcome of a prefetched page.
+type prefetchResult[P any] struct {
+	page *Response[P]
+	err  error
+}
+
+// prefetchStrategy is a Strategy prefetching the next page.
+type prefetchStrategy[P, T any] struct {
+	Strategy[P, T]
+}
+
+func (prefetchStrategy[P, T]) prefetch() {}
+
+// Prefetch requests the next page as soon as a page is received, while its
+// items are consumed, instead of when they are exhausted.
+func Prefetch[P, T any](strategy Strategy[P, T]) Strategy[P, T] {
+	return prefetchStrategy[P, T]{Strategy: strategy}
+}
+
+// linkStrategy follows the next link of the Link header.
+type linkStrategy[P, T any] struct {
+	items func(P) []T
+}
+
+// LinkHeader returns a Strategy following the RFC 8288 Link header with the
+// "next" relation, items being extracted from a page with items. The pagination
+// fails with ErrCrossOriginLink on a next link to another scheme or host than
+// the page.
+func LinkHeader[P, T any](items func(P) []T) Strategy[P, T] {
+	return linkStrategy[P, T]{items: items}
+}
+
+// Items returns the items of the page.
+func (s linkStrategy[P, T]) Items(page *Response[P]) []T {
+	return s.items(page.Body)
+}
+
+// Next requests the next link of the page.
+func (s linkStrategy[P, T]) Next(page *Response[P], _ Position) ([]do.Option, bool) {
+	if page == nil {
+		return nil, true
+	}
+
+	next, ok := NextLink(page.Header)
+	if !ok {
+		return nil, false
+	}
+
+	u, err := url.Parse(next)
+	if err != nil {
+		return nil, false
+	}
+
+	if page.URL != nil {
+		u = page.URL.ResolveReference(u)
+
+		if u.Scheme != page.URL.Scheme || u.Host != page.URL.Host {
+			return []do.Option{withError(fmt.Errorf("%w: %s", ErrCrossOriginLink, u.Redacted()))}, true
+		}
+	}
+
+	return []do.Option{withURL(u)}, true
+}
+
+// NextLink returns the target of the "next" relation of the Link header, as defined by RFC 8288.
+func NextLink(h http.Header) (string, bool) {
+	for _, v := range h.Values("Link") {
+		for v != "" {
+			start := strings.IndexByte(v, '<')
+			end := strings.IndexByte(v, '>')
+			if start < 0 || end < start {
+				break
+			}
+
+			target := v[start+1 : end]
+			v = v[end+1:]
+
+			params := v
+			if i := strings.IndexByte(v, '<'); i >= 0 {
+				params, v = v[:i], v[i:]
+			} else {
+				v = ""
+			}
+
+			for param := range strings.SplitSeq(params, ";") {
+				name, value, _ := strings.Cut(strings.TrimSpace(strings.TrimRight(param, ", ")), "=")
+				if !strings.EqualFold(strings.TrimSpace(name), "rel") {
+					continue
+				}
+
+				for rel := range strings.FieldsSeq(strings.Trim(strings.TrimSpace(value), `"`)) {
+					if strings.EqualFold(rel, "next") {
+						return target, true
+					}
+				}
+			}
+		}
+	}
+
+	return "", false
+}
+
+// withURL sends the request to u instead of the client base URL. It runs before
+// the other pre-request handlers, so that the query parameters they add, such as
+// an API key, are kept on every page. Every attempt gets its own copy of u, which
+// those handlers modify.
+func withURL(u *url.URL) do.Option {
+	return do.WithPreRequestHandler(
+		paginationURLHandler,
+		func(_ context.Context, req *http.Request) error {
+			cloned := *u
+			req.URL = &cloned
+			req.Host = u.Host
+			return nil
+		},
+		do.Priority(do.PriorityURL),
+	)
+}
+
+// withError fails the request with err before it is sent.
+func withError(err error) do.Option {
+	return do.WithPreRequestHandler(
+		paginationURLHandler,
+		func(context.Context, *http.Request) error {
+			return err
+		},
+		do.Priority(do.PriorityURL),
+	)
+}
+
+// cursorStrategy passes the cursor of a page as a query parameter.
+type cursorStrategy[P, T any] struct {
+	param  string
+	items  func(P) []T
+	cursor func(P) string
+}
+
+// Cursor returns a Strategy passing the cursor token found in a page body with
+// cursor as the param query parameter of the next request. The pagination ends
+// on an empty cursor.
+func Cursor[P, T any](param string, items func(P) []T, cursor func(P) string) Strategy[P, T] {
+	return cursorStrategy[P, T]{param: param, items: items, cursor: cursor}
+}
+
+// Items returns the items of the page.
+func (s cursorStrategy[P, T]) Items(page *Response[P]) []T {
+	return s.items(page.Body)
+}
+
+// Next requests the page of the cursor of page.
+func (s cursorStrategy[P, T]) Next(page *Response[P], _ Position) ([]do.Option, bool) {
+	if page == nil {
+		return nil, true
+	}
+
+	cursor := s.cursor(page.Body)
+	if cursor == "" {
+		return nil, false
+	}
+
+	return []do.Option{do.WithQuery(s.param, cursor)}, true
+}
+
+// offsetStrategy requests pages by offset and limit.
+type offsetStrategy[P, T any] struct {
+	offsetParam string
+	limitParam  string
+	limit       int
+	items       func(P) []T
+}
+
+// Offset returns a Strategy requesting pages of limit items with the
+// offsetParam and limitParam query parameters. The pagination ends on a page
+// with fewer than limit items.
+func Offset[P, T any](offsetParam, limitParam string, limit int, items func(P) []T) Strategy[P, T] {
+	return offsetStrategy[P, T]{offsetParam: offsetParam, limitParam: limitParam, limit: limit, items: items}
+}
+
+// Items returns the items of the page.
+func (s offsetStrategy[P, T]) Items(page *Response[P]) []T {
+	return s.items(page.Body)
+}
+
+// Next requests the items following those already received.
+func (s offsetStrategy[P, T]) Next(page *Response[P], pos Position) ([]do.Option, bool) {
+	if page != nil && len(s.Items(page)) < s.limit {
+		return nil, false
+	}
+
+	return []do.Option{
+		do.WithQuery(s.offsetParam, strconv.Itoa(pos.Items)),
+		do.WithQuery(s.limitParam, strconv.Itoa(s.limit)),
+	}, true
+}
+
+// pageNumberStrategy requests pages by number.
+type pageNumberStrategy[P, T any] struct {
+	pageParam string
+	sizeParam string
+	size      int
+	items     func(P) []T
+}
+
+// PageNumber returns a Strategy requesting pages numbered from 1 with the
+// pageParam query parameter, and their size with the sizeParam query parameter
+// unless it is empty. The pagination ends on an empty page, or on a page with
+// fewer than size items when size is set.
+func PageNumber[P, T any](pageParam, sizeParam string, size int, items func(P) []T) Strategy[P, T] {
+	return pageNumberStrategy[P, T]{pageParam: pageParam, sizeParam: sizeParam, size: size, items: items}
+}
+
+// Items returns the items of the page.
+func (s pageNumberStrategy[P, T]) Items(page *Response[P]) []T {
+	return s.items(page.Body)
+}
+
+// Next requests the page following page.
+func (s pageNumberStrategy[P, T]) Next(page *Response[P], pos Position) ([]do.Option, bool) {
+	if page != nil {
+		n := len(s.Items(page))
+		if n == 0 || (s.size > 0 && n < s.size) {
+			return nil, false
+		}
+	}
+
+	options := []do.Option{do.WithQuery(s.pageParam, strconv.Itoa(pos.Pages+1))}
+	if s.sizeParam != "" {
+		options = append(options, do.WithQuery(s.sizeParam, strconv.Itoa(s.size)))
+	}
+
+	return options, true
+}
@@ -0,0 +1,246 @@
+package rest_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/merlindorin/go-shared/pkg/must"
+	"github.com/merlindorin/go-shared/pkg/net/do"
+	"github.com/merlindorin/go-shared/pkg/net/do/auth"
+	"github.com/merlindorin/go-shared/pkg/net/do/retry"
+	"github.com/merlindorin/go-shared/pkg/net/rest"
+)
+
+type userPage struct {
+	Users []user `json:"users"`
+	Next  string `json:"next"`
+}
+
+func jsonResponse(header http.Header, body string) *http.Response {
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set("Content-Type", "application/json")
+
+	return &http.Response{StatusCode: http.StatusOK, Header: header, Body: io.NopCloser(strings.NewReader(body))}
+}
+
+func collect[T any](t *testing.T, seq func(yield func(T, error) bool)) []T {
+	t.Helper()
+
+	var items []T
+	for item, err := range seq {
+		if err != nil {
+			t.Fatal(err)
+		}
+		items = append(items, item)
+	}
+
+	return items
+}
+
+func TestPaginate(t *testing.T) {
+	baseURL := must.Get(url.Parse("https://merlindorin.com/api"))
+
+	t.Run("should follow the next link", func(t *testing.T) {
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).RunAndReturn(func(req *http.Request) (*http.Response, error) {
+			switch req.URL.String() {
+			case "https://merlindorin.com/api/users":
+				return jsonResponse(http.Header{
+					"Link": {`<https://merlindorin.com/api/users?page=2>; rel="next", </api/users?page=9>; rel="last"`},
+				}, `[{"id":1},{"id":2}]`), nil
+			case "https://merlindorin.com/api/users?page=2":
+				return jsonResponse(http.Header{"Link": {`</api/users?page=3>; rel="next"`}}, `[{"id":3}]`), nil
+			case "https://merlindorin.com/api/users?page=3":
+				return jsonResponse(nil, `[{"id":4}]`), nil
+			default:
+				return nil, fmt.Errorf("unexpected request %s", req.URL)
+			}
+		}).Times(3)
+
+		r := rest.NewRest(baseURL, do.WithClient(mockClient))
+		users := collect(t, rest.Paginate[user](t.Context(), r, rest.LinkHeader(rest.Items[user]), do.WithPath("/users")))
+
+		assert.Equal(t, []user{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}}, users)
+	})
+
+	t.Run("should keep the query parameters of the options on the next pages", func(t *testing.T) {
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).RunAndReturn(func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, "secret", req.URL.Query().Get("api_key"))
+			assert.Equal(t, "active", req.URL.Query().Get("status"))
+
+			if req.URL.Query().Get("page") == "" {
+				return jsonResponse(http.Header{"Link": {`</api/users?page=2>; rel="next"`}}, `[{"id":1}]`), nil
+			}
+			return jsonResponse(nil, `[{"id":2}]`), nil
+		}).Twice()
+
+		r := rest.NewRest(baseURL, do.WithClient(mockClient), auth.WithAPIKeyQuery("api_key", "secret"))
+		users := collect(t, rest.Paginate[user](
+			t.Context(),
+			r,
+			rest.LinkHeader(rest.Items[user]),
+			do.WithPath("/users"),
+			do.WithQuery("status", "active"),
+		))
+
+		assert.Equal(t, []user{{ID: 1}, {ID: 2}}, users)
+	})
+
+	t.Run("should keep the query parameters of the options on retried pages", func(t *testing.T) {
+		var queries []string
+
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).RunAndReturn(func(req *http.Request) (*http.Response, error) {
+			queries = append(queries, req.URL.RawQuery)
+
+			switch len(queries) {
+			case 1:
+				return jsonResponse(http.Header{"Link": {`</api/users?page=2>; rel="next"`}}, `[{"id":1}]`), nil
+			case 2:
+				return nil, fmt.Errorf("connection reset")
+			default:
+				return jsonResponse(nil, `[{"id":2}]`), nil
+			}
+		}).Times(3)
+
+		r := rest.NewRest(baseURL,
+			do.WithClient(mockClient),
+			do.WithRetry(retry.NewPolicy(retry.WithBackoff(retry.Constant(0)))),
+		)
+		users := collect(t, rest.Paginate[user](
+			t.Context(),
+			r,
+			rest.LinkHeader(rest.Items[user]),
+			do.WithPath("/users"),
+			do.WithQuery("status", "active"),
+		))
+
+		assert.Equal(t, []user{{ID: 1}, {ID: 2}}, users)
+		assert.Equal(t, []string{"status=active", "page=2&status=active", "page=2&status=active"}, queries)
+	})
+
+	t.Run("should not follow a next link to another origin", func(t *testing.T) {
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).RunAndReturn(func(*http.Request) (*http.Response, error) {
+			return jsonResponse(http.Header{"Link": {`<https://evil.example.com/users?page=2>; rel="next"`}}, `[{"id":1}]`), nil
+		}).Once()
+
+		r := rest.NewRest(baseURL, do.WithClient(mockClient), auth.WithAPIKeyQuery("api_key", "secret"))
+
+		var users []user
+		var err error
+		for item, e := range rest.Paginate[user](t.Context(), r, rest.LinkHeader(rest.Items[user])) {
+			if e != nil {
+				err = e
+				break
+			}
+			users = append(users, item)
+		}
+
+		assert.Equal(t, []user{{ID: 1}}, users)
+		assert.ErrorIs(t, err, rest.ErrCrossOriginLink)
+	})
+
+	t.Run("should pass the cursor of the page body", func(t *testing.T) {
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).RunAndReturn(func(req *http.Request) (*http.Response, error) {
+			if req.URL.Query().Get("cursor") == "" {
+				return jsonResponse(nil, `{"users":[{"id":1}],"next":"abc"}`), nil
+			}
+			return jsonResponse(nil, `{"users":[{"id":2}]}`), nil
+		}).Twice()
+
+		r := rest.NewRest(baseURL, do.WithClient(mockClient))
+		strategy := rest.Cursor("cursor",
+			func(p userPage) []user { return p.Users },
+			func(p userPage) string { return p.Next },
+		)
+
+		assert.Equal(t, []user{{ID: 1}, {ID: 2}}, collect(t, rest.Paginate(t.Context(), r, strategy)))
+	})
+
+	t.Run("should request pages by offset and prefetch them", func(t *testing.T) {
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).RunAndReturn(func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, "2", req.URL.Query().Get("limit"))
+
+			switch req.URL.Query().Get("offset") {
+			case "0":
+				return jsonResponse(nil, `[{"id":1},{"id":2}]`), nil
+			case "2":
+				return jsonResponse(nil, `[{"id":3},{"id":4}]`), nil
+			default:
+				return jsonResponse(nil, `[{"id":5}]`), nil
+			}
+		}).Times(3)
+
+		r := rest.NewRest(baseURL, do.WithClient(mockClient))
+		strategy := rest.Prefetch(rest.Offset("offset", "limit", 2, rest.Items[user]))
+
+		users := collect(t, rest.Paginate(t.Context(), r, strategy))
+		assert.Equal(t, []user{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}, {ID: 5}}, users)
+	})
+
+	t.Run("should request pages by number until an empty page", func(t *testing.T) {
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).RunAndReturn(func(req *http.Request) (*http.Response, error) {
+			page := must.Get(strconv.Atoi(req.URL.Query().Get("page")))
+			if page > 2 {
+				return jsonResponse(nil, `[]`), nil
+			}
+			return jsonResponse(nil, fmt.Sprintf(`[{"id":%d}]`, page)), nil
+		}).Times(3)
+
+		r := rest.NewRest(baseURL, do.WithClient(mockClient))
+		users := collect(t, rest.Paginate(t.Context(), r, rest.PageNumber("page", "", 0, rest.Items[user])))
+
+		assert.Equal(t, []user{{ID: 1}, {ID: 2}}, users)
+	})
+
+	t.Run("should stop when the context is done between pages", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		defer cancel()
+
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).RunAndReturn(func(*http.Request) (*http.Response, error) {
+			return jsonResponse(nil, `[{"id":1}]`), nil
+		}).Once()
+
+		r := rest.NewRest(baseURL, do.WithClient(mockClient))
+
+		var errs []error
+		for _, err := range rest.Paginate(ctx, r, rest.PageNumber("page", "", 0, rest.Items[user])) {
+			cancel()
+			errs = append(errs, err)
+		}
+
+		assert.Len(t, errs, 2)
+		assert.NoError(t, errs[0])
+		assert.ErrorIs(t, errs[1], context.Canceled)
+	})
+
+	t.Run("should stop when the loop breaks", func(t *testing.T) {
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).RunAndReturn(func(*http.Request) (*http.Response, error) {
+			return jsonResponse(nil, `[{"id":1},{"id":2}]`), nil
+		}).Once()
+
+		r := rest.NewRest(baseURL, do.WithClient(mockClient))
+
+		for range rest.Paginate(t.Context(), r, rest.PageNumber("page", "", 0, rest.Items[user])) {
+			break
+		}
+	})
+}
@@ -0,0 +1,243 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/merlindorin/go-shared/pkg/net/do"
+)
+
+const (
+	defaultMaxFailures  = 5
+	defaultEjectionTime = 30 * time.Second
+)
+
+// ErrNoEndpoint is returned when a balanced client has no endpoint to send a request to.
+var ErrNoEndpoint = errors.New("no endpoint available")
+
+// Endpoint is a base URL of a Pool along with its load and health.
+type Endpoint struct {
+	url *url.URL
+
+	inFlight atomic.Int64
+
+	mu           sync.Mutex
+	failures     int
+	ejectedUntil time.Time
+}
+
+// URL returns the base URL of the endpoint.
+func (e *Endpoint) URL() *url.URL {
+	return e.url
+}
+
+// InFlight returns the number of requests in flight to the endpoint.
+func (e *Endpoint) InFlight() int64 {
+	return e.inFlight.Load()
+}
+
+// Pool is a set of endpoints that can be updated at runtime. Endpoints failing
+// consecutively are ejected for a while: passive outlier detection. It is safe
+// for concurrent use.
+type Pool struct {
+	maxFailures  int
+	ejectionTime time.Duration
+	now          func() time.Time
+
+	mu        sync.RWMutex
+	endpoints []*Endpoint
+}
+
+// PoolOption represents a configuration setting that can be applied to a Pool.
+type PoolOption func(p *Pool)
+
+// apply sets the given PoolOption to the Pool.
+func (o PoolOption) apply(p *Pool) {
+	o(p)
+}
+
+// WithMaxFailures sets the number of consecutive failures ejecting an endpoint.
+func WithMaxFailures(n int) PoolOption {
+	return func(p *Pool) {
+		p.maxFailures = max(n, 1)
+	}
+}
+
+// WithEjectionTime sets how long a failing endpoint is ejected.
+func WithEjectionTime(d time.Duration) PoolOption {
+	return func(p *Pool) {
+		p.ejectionTime = d
+	}
+}
+
+// WithPoolNow sets the clock of the Pool.
+func WithPoolNow(now func() time.Time) PoolOption {
+	return func(p *Pool) {
+		p.now = now
+	}
+}
+
+// NewPool creates a Pool of the given base URLs. By default, an endpoint is
+// ejected for 30 seconds after 5 consecutive failures, failures being transport
+// errors and 5xx responses.
+func NewPool(urls []*url.URL, opts ...PoolOption) *Pool {
+	defaultOptions := []PoolOption{
+		WithMaxFailures(defaultMaxFailures),
+		WithEjectionTime(defaultEjectionTime),
+		WithPoolNow(time.Now),
+	}
+
+	p := &Pool{}
+
+	for _, opt := range append(defaultOptions, opts...) {
+		opt.apply(p)
+	}
+
+	p.Set(urls...)
+
+	return p
+}
+
+// Set replaces the endpoints of the pool. Endpoints already in the pool keep their state.
+func (p *Pool) Set(urls ...*url.URL) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	endpoints := make([]*Endpoint, 0, len(urls))
+	for _, u := range urls {
+		if i := p.index(u); i >= 0 {
+			endpoints = append(endpoints, p.endpoints[i])
+		} else if !slices.ContainsFunc(endpoints, func(e *Endpoint) bool { return e.url.String() == u.String() }) {
+			endpoints = append(endpoints, &Endpoint{url: u})
+		}
+	}
+
+	p.endpoints = endpoints
+}
+
+// Add adds an endpoint to the pool, if it is not already there.
+func (p *Pool) Add(u *url.URL) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.index(u) < 0 {
+		p.endpoints = append(slices.Clip(p.endpoints), &Endpoint{url: u})
+	}
+}
+
+// Remove removes an endpoint from the pool.
+func (p *Pool) Remove(u *url.URL) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if i := p.index(u); i >= 0 {
+		p.endpoints = slices.Delete(slices.Clone(p.endpoints), i, i+1)
+	}
+}
+
+// Endpoints returns every endpoint of the pool, including the ejected ones.
+func (p *Pool) Endpoints() []*Endpoint {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.endpoints
+}
+
+// URLs returns the base URLs of every endpoint of the pool.
+func (p *Pool) URLs() []*url.URL {
+	endpoints := p.Endpoints()
+
+	urls := make([]*url.URL, len(endpoints))
+	for i, e := range endpoints {
+		urls[i] = e.url
+	}
+
+	return urls
+}
+
+// Available returns the endpoints which are not ejected. When every endpoint
+// is ejected, all of them are returned so that requests are still attempted.
+func (p *Pool) Available() []*Endpoint {
+	endpoints := p.Endpoints()
+	now := p.now()
+
+	available := make([]*Endpoint, 0, len(endpoints))
+	for _, e := range endpoints {
+		e.mu.Lock()
+		ejected := now.Before(e.ejectedUntil)
+		e.mu.Unlock()
+
+		if !ejected {
+			available = append(available, e)
+		}
+	}
+
+	if len(available) == 0 {
+		return endpoints
+	}
+
+	return available
+}
+
+// Ejected reports whether the endpoint is currently ejected.
+func (p *Pool) Ejected(e *Endpoint) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return p.now().Before(e.ejectedUntil)
+}
+
+// observe records the outcome of a request sent to the endpoint.
+func (p *Pool) observe(e *Endpoint, res *http.Response, err error) {
+	failed := (err != nil && !errors.Is(err, context.Canceled)) ||
+		(res != nil && res.StatusCode >= http.StatusInternalServerError)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !failed {
+		e.failures = 0
+		return
+	}
+
+	e.failures++
+	if e.failures >= p.maxFailures {
+		e.failures = 0
+		e.ejectedUntil = p.now().Add(p.ejectionTime)
+	}
+}
+
+// index returns the position of the endpoint of u, or -1. The lock must be held.
+func (p *Pool) index(u *url.URL) int {
+	return slices.IndexFunc(p.endpoints, func(e *Endpoint) bool { return e.url.String() == u.String() })
+}
+
+// endpointClient is a do.HTTPClientDoer tracking the load and health of an endpoint.
+type endpointClient struct {
+	next     do.HTTPClientDoer
+	pool     *Pool
+	endpoint *Endpoint
+}
+
+// Do sends the request and records its outcome.
+func (c *endpointClient) Do(req *http.Request) (*http.Response, error) {
+	c.endpoint.inFlight.Add(1)
+
+	res, err := c.next.Do(req)
+	c.pool.observe(c.endpoint, res, err)
+
+	if err != nil || res.Body == nil {
+		c.endpoint.inFlight.Add(-1)
+		return res, err
+	}
+
+	res.Body = do.NewCountingBody(res.Body, func(int64) { c.endpoint.inFlight.Add(-1) })
+
+	return res, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http" // Correct the import path from "net/HTTP" to "net/http"
 	"net/url"
+	"slices"
 
 	"github.com/merlindorin/go-shared/pkg/net/do"
 )
@@ -14,29 +15,40 @@ type Rest struct {
 	do.Doer
 
 	baseOptions []do.Option
-	baseURL     *url.URL
+	send        do.D
 }
 
 // NewRest creates a new Rest client with a given base URL and options.
+// Responses with a 4xx or 5xx status code are returned as a *do.HTTPError,
+// use do.WithoutStatusCheck to disable it. Problem details bodies are decoded
+// by default, pass do.WithErrorDecoder to support other error envelopes and
+// do.WithCodec to change the default codec of the client.
 func NewRest(baseURL *url.URL, options ...do.Option) *Rest {
+	send := do.D(func(ctx context.Context, options ...do.Option) error {
+		return do.Do(ctx, baseURL, options...)
+	})
+
+	return newRest(send, append([]do.Option{do.WithStatusCheck()}, options...))
+}
+
+// newRest creates a Rest client sending requests through send with the given base options.
+func newRest(send do.D, baseOptions []do.Option) *Rest {
 	r := &Rest{
-		baseOptions: options,
-		baseURL:     baseURL,
+		baseOptions: baseOptions,
+		send:        send,
 	}
 
 	r.Doer = do.D(func(ctx context.Context, options ...do.Option) error {
-		return do.Do(ctx, baseURL, append(r.baseOptions, options...)...)
+		return r.send(ctx, append(slices.Clip(r.baseOptions), options...)...)
 	})
 
 	return r
 }
 
-// New create a new instance with new default options.
+// New create a new instance with new default options. The base options of the
+// parent, and the state they hold such as rate limiters, are shared with the child.
 func (r *Rest) New(options ...do.Option) Requester {
-	return &Rest{
-		baseOptions: append(r.baseOptions, options...),
-		baseURL:     r.baseURL,
-	}
+	return newRest(r.send, append(slices.Clip(r.baseOptions), options...))
 }
 
 // GET performs an HTTP GET request with the specified options.
@@ -1,11 +1,13 @@
 package rest_test
 
 import (
+	"context"
 	"io"
 	"net/http"
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -72,4 +74,55 @@ func TestNewRest(t *testing.T) {
 			})
 		}
 	})
+
+	t.Run("should check the response status by default", func(t *testing.T) {
+		wantURL := must.Get(url.Parse("https://merlindorin.com"))
+
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).Return(&http.Response{
+			StatusCode: http.StatusInternalServerError,
+			Body:       io.NopCloser(strings.NewReader("")),
+		}, nil).Twice()
+
+		r := rest.NewRest(wantURL, do.WithClient(mockClient))
+
+		assert.ErrorIs(t, r.GET(t.Context()), do.ErrServerError)
+		assert.NoError(t, r.GET(t.Context(), do.WithoutStatusCheck()))
+	})
+}
+
+func TestRest_New(t *testing.T) {
+	t.Run("should send requests with the parent options", func(t *testing.T) {
+		wantURL := must.Get(url.Parse("https://merlindorin.com"))
+		matchRequest := mock.MatchedBy(func(req *http.Request) bool {
+			return req.Header.Get("X-Parent") == "true" && req.Header.Get("X-Child") == "true"
+		})
+
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(matchRequest).Return(&http.Response{Body: io.NopCloser(strings.NewReader(""))}, nil).Once()
+
+		r := rest.NewRest(wantURL, do.WithClient(mockClient), do.WithExtraHeader("X-Parent", "true"))
+		child := r.New(do.WithExtraHeader("X-Child", "true"))
+
+		assert.NoError(t, child.GET(t.Context()))
+	})
+
+	t.Run("should share the rate limiter with its children", func(t *testing.T) {
+		wantURL := must.Get(url.Parse("https://merlindorin.com"))
+
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {"60"}},
+			Body:       io.NopCloser(strings.NewReader("")),
+		}, nil).Once()
+
+		r := rest.NewRest(wantURL, do.WithClient(mockClient), do.WithRateLimiter(do.NewTokenBucket(100, 10)))
+		assert.NoError(t, r.GET(t.Context()))
+
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+		defer cancel()
+
+		assert.ErrorIs(t, r.New().GET(ctx), context.DeadlineExceeded)
+	})
 }
@@ -0,0 +1,62 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/merlindorin/go-shared/pkg/net/do"
+)
+
+// Response is a decoded response body along with the response metadata.
+type Response[T any] struct {
+	do.Response
+
+	// Body is the decoded response body.
+	Body T
+}
+
+// Do performs a request with the given options and decodes the response body
+// into a T with the Codec, JSON unless set with do.WithCodec. When the request
+// fails after a response is received, such as with a *do.HTTPError, the response
+// is returned along with the error, with its metadata filled and its body left
+// undecoded.
+func Do[T any](ctx context.Context, r Requester, options ...do.Option) (*Response[T], error) {
+	res := &Response[T]{}
+
+	err := r.Do(ctx, append(options, withResponse(res))...)
+
+	return res, err
+}
+
+// Get performs a GET request and decodes the response body into a T.
+func Get[T any](ctx context.Context, r Requester, options ...do.Option) (*Response[T], error) {
+	return Do[T](ctx, r, append(options, do.WithMethod(http.MethodGet))...)
+}
+
+// Delete performs a DELETE request and decodes the response body into a T.
+func Delete[T any](ctx context.Context, r Requester, options ...do.Option) (*Response[T], error) {
+	return Do[T](ctx, r, append(options, do.WithMethod(http.MethodDelete))...)
+}
+
+// Post performs a POST request with in as the encoded body and decodes the response body into an Out.
+func Post[In, Out any](ctx context.Context, r Requester, in In, options ...do.Option) (*Response[Out], error) {
+	return Do[Out](ctx, r, append(options, do.WithMarshalBody(in), do.WithMethod(http.MethodPost))...)
+}
+
+// Put performs a PUT request with in as the encoded body and decodes the response body into an Out.
+func Put[In, Out any](ctx context.Context, r Requester, in In, options ...do.Option) (*Response[Out], error) {
+	return Do[Out](ctx, r, append(options, do.WithMarshalBody(in), do.WithMethod(http.MethodPut))...)
+}
+
+// Patch performs a PATCH request with in as the encoded body and decodes the response body into an Out.
+func Patch[In, Out any](ctx context.Context, r Requester, in In, options ...do.Option) (*Response[Out], error) {
+	return Do[Out](ctx, r, append(options, do.WithMarshalBody(in), do.WithMethod(http.MethodPatch))...)
+}
+
+// withResponse fills res with the response metadata and the decoded body.
+func withResponse[T any](res *Response[T]) do.Option {
+	return func(params *do.Params) {
+		do.WithResponseInto(&res.Response).Apply(params)
+		do.WithUnmarshalBody(&res.Body).Apply(params)
+	}
+}
@@ -0,0 +1,82 @@
+package rest_test
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/merlindorin/go-shared/pkg/must"
+	"github.com/merlindorin/go-shared/pkg/net/do"
+	"github.com/merlindorin/go-shared/pkg/net/rest"
+)
+
+type user struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestTyped(t *testing.T) {
+	baseURL := must.Get(url.Parse("https://merlindorin.com"))
+
+	t.Run("should decode the response body with its metadata", func(t *testing.T) {
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.MatchedBy(func(req *http.Request) bool {
+			return req.Method == http.MethodGet && req.URL.Path == "/users/1"
+		})).Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Etag": []string{`"v1"`}},
+			Body:       io.NopCloser(strings.NewReader(`{"id": 1, "name": "merlin"}`)),
+		}, nil).Once()
+
+		r := rest.NewRest(baseURL, do.WithClient(mockClient))
+		res, err := rest.Get[user](t.Context(), r, do.WithPath("/users/%d", 1))
+
+		assert.NoError(t, err)
+		assert.Equal(t, user{ID: 1, Name: "merlin"}, res.Body)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, `"v1"`, res.Header.Get("Etag"))
+		assert.Equal(t, "https://merlindorin.com/users/1", res.URL.String())
+	})
+
+	t.Run("should encode the request body", func(t *testing.T) {
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).RunAndReturn(func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, http.MethodPost, req.Method)
+			assert.JSONEq(t, `{"id": 0, "name": "merlin"}`, string(must.Get(io.ReadAll(req.Body))))
+
+			return &http.Response{
+				StatusCode: http.StatusCreated,
+				Body:       io.NopCloser(strings.NewReader(`{"id": 2, "name": "merlin"}`)),
+			}, nil
+		}).Once()
+
+		r := rest.NewRest(baseURL, do.WithClient(mockClient))
+		res, err := rest.Post[user, user](t.Context(), r, user{Name: "merlin"}, do.WithPath("/users"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, user{ID: 2, Name: "merlin"}, res.Body)
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+	})
+
+	t.Run("should return the status error along with the response metadata", func(t *testing.T) {
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).Return(&http.Response{
+			StatusCode: http.StatusNotFound,
+			Header:     http.Header{"X-Request-Id": []string{"42"}},
+			Body:       io.NopCloser(strings.NewReader(`{"id": 3}`)),
+		}, nil).Once()
+
+		r := rest.NewRest(baseURL, do.WithClient(mockClient))
+		res, err := rest.Delete[user](t.Context(), r)
+
+		assert.ErrorIs(t, err, do.ErrNotFound)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+		assert.Equal(t, "42", res.Header.Get("X-Request-Id"))
+		assert.Equal(t, user{}, res.Body)
+	})
+}
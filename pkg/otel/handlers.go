@@ -0,0 +1,46 @@
+package otel
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/merlindorin/go-shared/pkg/net/do"
+)
+
+// HandlerEventName is the name of the span events added by WithHandlerEvents.
+const HandlerEventName = "do.handler"
+
+// Attributes of the span events added by WithHandlerEvents.
+const (
+	HandlerKindKey     = attribute.Key("do.handler.kind")
+	HandlerNameKey     = attribute.Key("do.handler.name")
+	HandlerDurationKey = attribute.Key("do.handler.duration")
+	HandlerErrorKey    = attribute.Key("do.handler.error")
+)
+
+// WithHandlerEvents adds a span event to the span of the request context for
+// every named pre-request, post-request and error handler run by do.Do, with
+// the kind, name, duration in seconds and error of the handler. The handlers
+// run outside of the spans of WithOtelhttp, so the events are added to the span
+// started by the caller around the request.
+func WithHandlerEvents() do.Option {
+	return do.WithHandlerObserver(func(ctx context.Context, event do.HandlerEvent) {
+		span := trace.SpanFromContext(ctx)
+		if !span.IsRecording() {
+			return
+		}
+
+		attrs := []attribute.KeyValue{
+			HandlerKindKey.String(event.Kind),
+			HandlerNameKey.String(event.Name),
+			HandlerDurationKey.Float64(event.Duration.Seconds()),
+		}
+		if event.Err != nil {
+			attrs = append(attrs, HandlerErrorKey.String(event.Err.Error()))
+		}
+
+		span.AddEvent(HandlerEventName, trace.WithTimestamp(event.Start), trace.WithAttributes(attrs...))
+	})
+}
@@ -0,0 +1,71 @@
+package otel_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/merlindorin/go-shared/pkg/must"
+	"github.com/merlindorin/go-shared/pkg/net/do"
+	"github.com/merlindorin/go-shared/pkg/otel"
+)
+
+func TestWithHandlerEvents(t *testing.T) {
+	baseURL := must.Get(url.Parse("https://merlindorin.com"))
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	mockClient := do.NewMockHttpClientDoer(t)
+	mockClient.EXPECT().Do(mock.Anything).Return(&http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader("{}")),
+	}, nil).Once()
+
+	ctx, span := tp.Tracer("test").Start(t.Context(), "request")
+
+	err := do.Do(ctx, baseURL,
+		do.WithClient(mockClient),
+		do.WithExtraHeader("X-Test", "test"),
+		do.WithPostRequestHandler("failing", func(context.Context, *http.Request, *http.Response) error {
+			return errors.New("boom")
+		}),
+		otel.WithHandlerEvents(),
+	)
+	assert.Error(t, err)
+
+	span.End()
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 1)
+
+	events := spans[0].Events()
+	assert.Len(t, events, 2)
+
+	for _, event := range events {
+		assert.Equal(t, otel.HandlerEventName, event.Name)
+	}
+
+	attrs := attribute.NewSet(events[0].Attributes...)
+	kind, _ := attrs.Value(otel.HandlerKindKey)
+	name, _ := attrs.Value(otel.HandlerNameKey)
+	assert.Equal(t, do.PreRequestHandlerKind, kind.AsString())
+	assert.Equal(t, "http_request_set_header_X-Test", name.AsString())
+	assert.False(t, attrs.HasValue(otel.HandlerErrorKey))
+
+	attrs = attribute.NewSet(events[1].Attributes...)
+	kind, _ = attrs.Value(otel.HandlerKindKey)
+	failure, _ := attrs.Value(otel.HandlerErrorKey)
+	assert.Equal(t, do.PostRequestHandlerKind, kind.AsString())
+	assert.Equal(t, "boom", failure.AsString())
+}
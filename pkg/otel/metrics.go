@@ -0,0 +1,170 @@
+package otel
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/semconv/v1.37.0/httpconv"
+
+	"github.com/merlindorin/go-shared/pkg/net/do"
+)
+
+// ScopeName is the instrumentation scope of the meters and tracers of this package.
+const ScopeName = "github.com/merlindorin/go-shared/pkg/otel"
+
+// ClientMetrics holds the OpenTelemetry HTTP client metrics, following the
+// HTTP semantic conventions.
+type ClientMetrics struct {
+	duration     httpconv.ClientRequestDuration
+	requestSize  httpconv.ClientRequestBodySize
+	responseSize httpconv.ClientResponseBodySize
+	active       httpconv.ClientActiveRequests
+}
+
+// NewClientMetrics creates the HTTP client metrics with a meter of mp:
+// http.client.request.duration, http.client.request.body.size,
+// http.client.response.body.size and http.client.active_requests.
+func NewClientMetrics(mp metric.MeterProvider) (*ClientMetrics, error) {
+	meter := mp.Meter(ScopeName)
+
+	duration, err := httpconv.NewClientRequestDuration(meter)
+	if err != nil {
+		return nil, err
+	}
+
+	requestSize, err := httpconv.NewClientRequestBodySize(meter)
+	if err != nil {
+		return nil, err
+	}
+
+	responseSize, err := httpconv.NewClientResponseBodySize(meter)
+	if err != nil {
+		return nil, err
+	}
+
+	active, err := httpconv.NewClientActiveRequests(meter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ClientMetrics{
+		duration:     duration,
+		requestSize:  requestSize,
+		responseSize: responseSize,
+		active:       active,
+	}, nil
+}
+
+// Option returns a do.Option recording the HTTP client semantic-convention
+// metrics of every attempt of the request. The url.template attribute is the
+// format given to do.WithPath, which keeps the cardinality under control.
+func (m *ClientMetrics) Option() do.Option {
+	return func(params *do.Params) {
+		do.WithClientDecorator(func(next do.HTTPClientDoer) do.HTTPClientDoer {
+			return &metricsClient{next: next, params: params, metrics: m}
+		}).Apply(params)
+	}
+}
+
+// metricsClient is a do.HTTPClientDoer recording the client metrics.
+type metricsClient struct {
+	next    do.HTTPClientDoer
+	params  *do.Params
+	metrics *ClientMetrics
+}
+
+// Do sends the request and records its metrics once the response body is read to
+// the end or closed.
+func (c *metricsClient) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	method := requestMethod(req.Method)
+	address, port := serverAddress(req)
+
+	attrs := []attribute.KeyValue{c.metrics.active.AttrURLScheme(req.URL.Scheme)}
+	if c.params.PathTemplate != "" {
+		attrs = append(attrs, c.metrics.duration.AttrURLTemplate(c.params.PathTemplate))
+	}
+	attrs = slices.Clip(attrs)
+
+	start := time.Now()
+	c.metrics.active.Add(ctx, 1, address, port, append(attrs, c.metrics.active.AttrRequestMethod(method))...)
+
+	if req.ContentLength > 0 {
+		c.metrics.requestSize.Record(ctx, req.ContentLength, method, address, port, attrs...)
+	}
+
+	res, err := c.next.Do(req)
+	if err != nil || res.Body == nil {
+		c.observe(ctx, req, res, err, start, 0, attrs)
+		return res, err
+	}
+
+	res.Body = do.NewCountingBody(res.Body, func(read int64) {
+		c.observe(ctx, req, res, nil, start, read, attrs)
+	})
+
+	return res, nil
+}
+
+// observe records a finished request.
+func (c *metricsClient) observe(
+	ctx context.Context,
+	req *http.Request,
+	res *http.Response,
+	err error,
+	start time.Time,
+	received int64,
+	attrs []attribute.KeyValue,
+) {
+	method := requestMethod(req.Method)
+	address, port := serverAddress(req)
+
+	c.metrics.active.Add(ctx, -1, address, port, append(attrs, c.metrics.active.AttrRequestMethod(method))...)
+
+	switch {
+	case err != nil:
+		attrs = append(attrs, c.metrics.duration.AttrErrorType(httpconv.ErrorTypeOther))
+	case res.StatusCode >= http.StatusBadRequest:
+		attrs = append(attrs,
+			c.metrics.duration.AttrResponseStatusCode(res.StatusCode),
+			c.metrics.duration.AttrErrorType(httpconv.ErrorTypeAttr(strconv.Itoa(res.StatusCode))),
+		)
+	default:
+		attrs = append(attrs, c.metrics.duration.AttrResponseStatusCode(res.StatusCode))
+	}
+
+	c.metrics.duration.Record(ctx, time.Since(start).Seconds(), method, address, port, attrs...)
+
+	if res != nil {
+		c.metrics.responseSize.Record(ctx, received, method, address, port, attrs...)
+	}
+}
+
+// requestMethod returns the semantic-convention value of an HTTP method.
+func requestMethod(method string) httpconv.RequestMethodAttr {
+	switch method {
+	case http.MethodConnect, http.MethodDelete, http.MethodGet, http.MethodHead, http.MethodOptions,
+		http.MethodPatch, http.MethodPost, http.MethodPut, http.MethodTrace:
+		return httpconv.RequestMethodAttr(method)
+	default:
+		return httpconv.RequestMethodOther
+	}
+}
+
+// serverAddress returns the host and port the request is sent to.
+func serverAddress(req *http.Request) (string, int) {
+	if port, err := strconv.Atoi(req.URL.Port()); err == nil {
+		return req.URL.Hostname(), port
+	}
+
+	if req.URL.Scheme == "http" {
+		return req.URL.Hostname(), 80
+	}
+
+	return req.URL.Hostname(), 443
+}
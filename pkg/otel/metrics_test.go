@@ -0,0 +1,170 @@
+package otel_test
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"github.com/merlindorin/go-shared/pkg/must"
+	"github.com/merlindorin/go-shared/pkg/net/do"
+	"github.com/merlindorin/go-shared/pkg/otel"
+)
+
+func TestClientMetrics(t *testing.T) {
+	baseURL := must.Get(url.Parse("https://merlindorin.com"))
+
+	t.Run("should record the semantic-convention metrics", func(t *testing.T) {
+		reader := sdkmetric.NewManualReader()
+		mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).RunAndReturn(func(req *http.Request) (*http.Response, error) {
+			metrics := collect(t, reader)
+			assert.Equal(t, int64(1), sum(t, metrics, "http.client.active_requests"))
+
+			return &http.Response{
+				StatusCode: http.StatusNotFound,
+				Body:       io.NopCloser(strings.NewReader("not found")),
+			}, nil
+		}).Once()
+
+		err := do.Do(t.Context(), baseURL,
+			do.WithClient(mockClient),
+			do.WithMethod(http.MethodPost),
+			do.WithPath("/users/%d", 1),
+			do.WithBody(strings.NewReader("body")),
+			do.WithStatusCheck(),
+			must.Get(otel.NewClientMetrics(mp)).Option(),
+		)
+		require.ErrorIs(t, err, do.ErrNotFound)
+
+		metrics := collect(t, reader)
+		assert.Equal(t, int64(0), sum(t, metrics, "http.client.active_requests"))
+
+		duration := histogram[float64](t, metrics, "http.client.request.duration")
+		assert.Equal(t, uint64(1), duration.Count)
+		assert.ElementsMatch(t, []attribute.KeyValue{
+			attribute.String("http.request.method", http.MethodPost),
+			attribute.String("server.address", "merlindorin.com"),
+			attribute.Int("server.port", 443),
+			attribute.String("url.scheme", "https"),
+			attribute.String("url.template", "/users/%d"),
+			attribute.Int("http.response.status_code", http.StatusNotFound),
+			attribute.String("error.type", "404"),
+		}, duration.Attributes.ToSlice())
+
+		assert.Equal(t, int64(4), histogram[int64](t, metrics, "http.client.request.body.size").Sum)
+		assert.Equal(t, int64(9), histogram[int64](t, metrics, "http.client.response.body.size").Sum)
+	})
+
+	t.Run("should record decoded responses", func(t *testing.T) {
+		reader := sdkmetric.NewManualReader()
+		mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`{"name":"merlin"}`)),
+		}, nil).Once()
+
+		var got struct {
+			Name string `json:"name"`
+		}
+
+		err := do.Do(t.Context(), baseURL,
+			do.WithClient(mockClient),
+			do.WithUnmarshalBody(&got),
+			must.Get(otel.NewClientMetrics(mp)).Option(),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, "merlin", got.Name)
+
+		metrics := collect(t, reader)
+		assert.Equal(t, int64(0), sum(t, metrics, "http.client.active_requests"))
+		assert.Equal(t, uint64(1), histogram[float64](t, metrics, "http.client.request.duration").Count)
+		assert.Equal(t, int64(17), histogram[int64](t, metrics, "http.client.response.body.size").Sum)
+	})
+
+	t.Run("should record transport errors", func(t *testing.T) {
+		reader := sdkmetric.NewManualReader()
+		mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+		mockClient := do.NewMockHttpClientDoer(t)
+		mockClient.EXPECT().Do(mock.Anything).Return(nil, io.ErrUnexpectedEOF).Once()
+
+		err := do.Do(t.Context(), baseURL, do.WithClient(mockClient), must.Get(otel.NewClientMetrics(mp)).Option())
+		require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+		metrics := collect(t, reader)
+		duration := histogram[float64](t, metrics, "http.client.request.duration")
+		errorType, ok := duration.Attributes.Value("error.type")
+		assert.True(t, ok)
+		assert.Equal(t, "_OTHER", errorType.AsString())
+		assert.Equal(t, int64(0), sum(t, metrics, "http.client.active_requests"))
+	})
+}
+
+// collect returns the metrics of the reader.
+func collect(t *testing.T, reader *sdkmetric.ManualReader) []metricdata.Metrics {
+	t.Helper()
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(t.Context(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+
+	return rm.ScopeMetrics[0].Metrics
+}
+
+// find returns the metric with the given name.
+func find(t *testing.T, metrics []metricdata.Metrics, name string) metricdata.Metrics {
+	t.Helper()
+
+	for _, m := range metrics {
+		if m.Name == name {
+			return m
+		}
+	}
+
+	require.Failf(t, "metric not found", "no metric %s", name)
+
+	return metricdata.Metrics{}
+}
+
+// sum returns the total of the data points of an int64 sum.
+func sum(t *testing.T, metrics []metricdata.Metrics, name string) int64 {
+	t.Helper()
+
+	data, ok := find(t, metrics, name).Data.(metricdata.Sum[int64])
+	require.True(t, ok)
+
+	var total int64
+	for _, dp := range data.DataPoints {
+		total += dp.Value
+	}
+
+	return total
+}
+
+// histogram returns the single data point of a histogram.
+func histogram[N int64 | float64](
+	t *testing.T,
+	metrics []metricdata.Metrics,
+	name string,
+) metricdata.HistogramDataPoint[N] {
+	t.Helper()
+
+	data, ok := find(t, metrics, name).Data.(metricdata.Histogram[N])
+	require.True(t, ok)
+	require.Len(t, data.DataPoints, 1)
+
+	return data.DataPoints[0]
+}